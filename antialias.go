@@ -0,0 +1,192 @@
+package gc9307
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Anti-aliased shapes need the existing pixels to blend against, which the
+// display cannot provide cheaply. They are rendered into an *image.RGBA
+// canvas instead, which is then sent with FillRectangleWithImage.
+//
+// Coordinates are in canvas pixels: pixel (x, y) covers the unit square from
+// (x, y) to (x+1, y+1), so its center is at (x+0.5, y+0.5). Colors are
+// blended over the existing content with their alpha scaled by the covered
+// fraction of each pixel.
+
+// blendPixel blends c over the canvas pixel at (x, y) with coverage cov
+func blendPixel(dst *image.RGBA, x, y int, c color.RGBA, cov float64) {
+	if cov <= 0 || !(image.Point{x, y}.In(dst.Rect)) {
+		return
+	}
+	if cov > 1 {
+		cov = 1
+	}
+	i := dst.PixOffset(x, y)
+	p := dst.Pix[i : i+4 : i+4]
+	a := float64(c.A) / 255 * cov
+	p[0] = uint8(float64(c.R)*cov + float64(p[0])*(1-a) + 0.5)
+	p[1] = uint8(float64(c.G)*cov + float64(p[1])*(1-a) + 0.5)
+	p[2] = uint8(float64(c.B)*cov + float64(p[2])*(1-a) + 0.5)
+	p[3] = uint8(float64(c.A)*cov + float64(p[3])*(1-a) + 0.5)
+}
+
+// DrawLineAA draws a one pixel wide anti-aliased line with Xiaolin Wu's
+// algorithm.
+func DrawLineAA(dst *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	// Work with pixel centers at integer coordinates
+	x0, y0, x1, y1 = x0-0.5, y0-0.5, x1-0.5, y1-0.5
+
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0 = y0, x0
+		x1, y1 = y1, x1
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+		y0, y1 = y1, y0
+	}
+	plot := func(x, y int, cov float64) {
+		if steep {
+			x, y = y, x
+		}
+		blendPixel(dst, x, y, c, cov)
+	}
+
+	dx, dy := x1-x0, y1-y0
+	gradient := 1.0
+	if dx != 0 {
+		gradient = dy / dx
+	}
+
+	// First endpoint
+	xend := math.Floor(x0 + 0.5)
+	yend := y0 + gradient*(xend-x0)
+	xgap := 1 - frac(x0+0.5)
+	xpx1 := int(xend)
+	ypx1 := int(math.Floor(yend))
+	plot(xpx1, ypx1, (1-frac(yend))*xgap)
+	plot(xpx1, ypx1+1, frac(yend)*xgap)
+	intery := yend + gradient
+
+	// Second endpoint
+	xend = math.Floor(x1 + 0.5)
+	yend = y1 + gradient*(xend-x1)
+	xgap = frac(x1 + 0.5)
+	xpx2 := int(xend)
+	ypx2 := int(math.Floor(yend))
+	if xpx2 == xpx1 {
+		return
+	}
+	plot(xpx2, ypx2, (1-frac(yend))*xgap)
+	plot(xpx2, ypx2+1, frac(yend)*xgap)
+
+	// Everything in between
+	for x := xpx1 + 1; x < xpx2; x++ {
+		y := int(math.Floor(intery))
+		plot(x, y, 1-frac(intery))
+		plot(x, y+1, frac(intery))
+		intery += gradient
+	}
+}
+
+func frac(v float64) float64 {
+	return v - math.Floor(v)
+}
+
+// coverage turns a signed distance from a shape edge (negative inside) into
+// the covered fraction of a pixel
+func coverage(dist float64) float64 {
+	return math.Max(0, math.Min(1, 0.5-dist))
+}
+
+// shade blends c over every canvas pixel in the box whose center is closer
+// than the edge distance returned by dist
+func shade(dst *image.RGBA, box image.Rectangle, c color.RGBA, dist func(px, py float64) float64) {
+	box = box.Intersect(dst.Rect)
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			blendPixel(dst, x, y, c, coverage(dist(float64(x)+0.5, float64(y)+0.5)))
+		}
+	}
+}
+
+// aaBounds returns the pixels touched by a shape spanning the given extent
+func aaBounds(x0, y0, x1, y1 float64) image.Rectangle {
+	return image.Rect(int(math.Floor(x0))-1, int(math.Floor(y0))-1, int(math.Ceil(x1))+1, int(math.Ceil(y1))+1)
+}
+
+// DrawThickLineAA draws an anti-aliased line of the given width with round
+// caps.
+func DrawThickLineAA(dst *image.RGBA, x0, y0, x1, y1, width float64, c color.RGBA) {
+	hw := width / 2
+	box := aaBounds(math.Min(x0, x1)-hw, math.Min(y0, y1)-hw, math.Max(x0, x1)+hw, math.Max(y0, y1)+hw)
+	shade(dst, box, c, func(px, py float64) float64 {
+		return segmentDistance(px, py, x0, y0, x1, y1) - hw
+	})
+}
+
+// segmentDistance returns the distance from (px, py) to the segment
+func segmentDistance(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/l))
+	}
+	return math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+}
+
+// DrawCircleAA draws a one pixel wide anti-aliased circle outline.
+func DrawCircleAA(dst *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	DrawThickCircleAA(dst, cx, cy, r, 1, c)
+}
+
+// DrawThickCircleAA draws an anti-aliased circle outline of the given width,
+// centered on radius r.
+func DrawThickCircleAA(dst *image.RGBA, cx, cy, r, width float64, c color.RGBA) {
+	hw := width / 2
+	box := aaBounds(cx-r-hw, cy-r-hw, cx+r+hw, cy+r+hw)
+	shade(dst, box, c, func(px, py float64) float64 {
+		return math.Abs(math.Hypot(px-cx, py-cy)-r) - hw
+	})
+}
+
+// FillCircleAA draws an anti-aliased filled circle.
+func FillCircleAA(dst *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	box := aaBounds(cx-r, cy-r, cx+r, cy+r)
+	shade(dst, box, c, func(px, py float64) float64 {
+		return math.Hypot(px-cx, py-cy) - r
+	})
+}
+
+// DrawArcAA draws an anti-aliased arc of the given width with round caps,
+// from startAngle to endAngle in degrees. 0 is at 3 o'clock and angles grow
+// clockwise, as for Device.DrawArc.
+func DrawArcAA(dst *image.RGBA, cx, cy, r, width, startAngle, endAngle float64, c color.RGBA) {
+	if endAngle < startAngle {
+		startAngle, endAngle = endAngle, startAngle
+	}
+	if endAngle-startAngle >= 360 {
+		DrawThickCircleAA(dst, cx, cy, r, width, c)
+		return
+	}
+	start := startAngle * math.Pi / 180
+	sweep := (endAngle - startAngle) * math.Pi / 180
+	sx, sy := cx+r*math.Cos(start), cy+r*math.Sin(start)
+	ex, ey := cx+r*math.Cos(start+sweep), cy+r*math.Sin(start+sweep)
+
+	hw := width / 2
+	box := aaBounds(cx-r-hw, cy-r-hw, cx+r+hw, cy+r+hw)
+	shade(dst, box, c, func(px, py float64) float64 {
+		a := math.Mod(math.Atan2(py-cy, px-cx)-start, 2*math.Pi)
+		if a < 0 {
+			a += 2 * math.Pi
+		}
+		if a <= sweep {
+			return math.Abs(math.Hypot(px-cx, py-cy)-r) - hw
+		}
+		// Outside of the sweep the round caps are the closest part
+		return math.Min(math.Hypot(px-sx, py-sy), math.Hypot(px-ex, py-ey)) - hw
+	})
+}
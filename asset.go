@@ -0,0 +1,255 @@
+package gc9307
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Asset is an image converted ahead of time to the panel's pixel format, so
+// drawing it needs no decoding or color conversion. Assets are stored in a
+// small container, written by EncodeAsset or the gc9307-asset command:
+//
+//	offset size
+//	0      4    magic "G565"
+//	4      1    version, 1
+//	5      1    flags: 1 = RLE compressed pixels, 2 = alpha mask present
+//	6      2    width, big endian
+//	8      2    height, big endian
+//	10     4    length of the pixel data, big endian
+//	14     2    reserved, 0
+//	16          pixel data, then the alpha mask
+//
+// Pixels are big endian RGB565 as sent to the panel (see RGBATo565BGR), row
+// after row. When compressed, they are packets as in RLEImage. The mask is a
+// Bitmap of the same size whose set bits mark opaque pixels.
+type Asset struct {
+	Width, Height int
+	RLE           bool   // Pix holds packets rather than plain pixels
+	Pix           []byte // Pixel data as stored in the container
+	Mask          *Bitmap
+}
+
+// AssetOptions selects the optional features of EncodeAsset.
+type AssetOptions struct {
+	RLE    bool // Compress the pixels; best for flat colors
+	Alpha  bool // Add a mask of the pixels that are at least half opaque
+	Dither bool // Diffuse the error of reducing colors to RGB565 (Floyd-Steinberg)
+}
+
+const (
+	assetMagic   = "G565"
+	assetVersion = 1
+	assetRLE     = 1
+	assetMask    = 2
+)
+
+// NewAsset converts img according to opts.
+func NewAsset(img image.Image, opts AssetOptions) (*Asset, error) {
+	b := img.Bounds()
+	if b.Empty() || b.Dx() > 0xFFFF || b.Dy() > 0xFFFF {
+		return nil, errors.New("asset: invalid image size")
+	}
+	a := &Asset{Width: b.Dx(), Height: b.Dy(), RLE: opts.RLE}
+	pix := make([]byte, a.Width*a.Height*2)
+	if opts.Alpha {
+		a.Mask = NewBitmap(a.Width, a.Height)
+	}
+
+	// Quantization errors carried to the current and next row, per channel
+	var cur, next [][3]int32
+	if opts.Dither {
+		cur, next = make([][3]int32, a.Width+2), make([][3]int32, a.Width+2)
+	}
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			c := color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+			if a.Mask != nil {
+				a.Mask.Set(x, y, c.A >= 0x80)
+			}
+			if opts.Dither {
+				e := cur[x+1]
+				r, g, bl := clamp8(int32(c.R)+e[0]>>4), clamp8(int32(c.G)+e[1]>>4), clamp8(int32(c.B)+e[2]>>4)
+				c = color.RGBA{r, g, bl, c.A}
+				// What survives the reduction to 5, 6 and 5 bits
+				q := [3]int32{int32(r &^ 7), int32(g &^ 3), int32(bl &^ 7)}
+				for i, v := range [3]int32{int32(r), int32(g), int32(bl)} {
+					d := v - q[i]
+					cur[x+2][i] += d * 7
+					next[x][i] += d * 3
+					next[x+1][i] += d * 5
+					next[x+2][i] += d
+				}
+			}
+			put565(pix[(y*a.Width+x)*2:], RGBATo565BGR(c))
+		}
+		if opts.Dither {
+			cur, next = next, cur
+			for i := range next {
+				next[i] = [3]int32{}
+			}
+		}
+	}
+
+	a.Pix = pix
+	if opts.RLE {
+		a.Pix = encodeRLE565(pix)
+	}
+	return a, nil
+}
+
+// EncodeAsset writes img to w in the asset container format.
+func EncodeAsset(w io.Writer, img image.Image, opts AssetOptions) error {
+	a, err := NewAsset(img, opts)
+	if err != nil {
+		return err
+	}
+	return a.Encode(w)
+}
+
+// Encode writes a in the asset container format.
+func (a *Asset) Encode(w io.Writer) error {
+	var hdr [16]byte
+	copy(hdr[:], assetMagic)
+	hdr[4] = assetVersion
+	if a.RLE {
+		hdr[5] |= assetRLE
+	}
+	if a.Mask != nil {
+		hdr[5] |= assetMask
+	}
+	binary.BigEndian.PutUint16(hdr[6:], uint16(a.Width))
+	binary.BigEndian.PutUint16(hdr[8:], uint16(a.Height))
+	binary.BigEndian.PutUint32(hdr[10:], uint32(len(a.Pix)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(a.Pix); err != nil {
+		return err
+	}
+	if a.Mask != nil {
+		_, err := w.Write(a.Mask.Bits)
+		return err
+	}
+	return nil
+}
+
+// DecodeAsset reads an asset container.
+func DecodeAsset(r io.Reader) (*Asset, error) {
+	br := bufio.NewReader(r)
+	var hdr [16]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if string(hdr[:4]) != assetMagic {
+		return nil, errors.New("asset: not a G565 asset")
+	}
+	if hdr[4] != assetVersion {
+		return nil, errors.New("asset: unsupported version")
+	}
+	a := &Asset{
+		Width:  int(binary.BigEndian.Uint16(hdr[6:])),
+		Height: int(binary.BigEndian.Uint16(hdr[8:])),
+		RLE:    hdr[5]&assetRLE != 0,
+	}
+	n := binary.BigEndian.Uint32(hdr[10:])
+	if a.Width == 0 || a.Height == 0 {
+		return nil, errors.New("asset: invalid image size")
+	}
+	if !a.RLE && int64(n) != int64(a.Width)*int64(a.Height)*2 {
+		return nil, errors.New("asset: pixel data length does not match the size")
+	}
+	// Even single pixel runs take no more than 3 bytes per pixel
+	if int64(n) > int64(a.Width)*int64(a.Height)*3 {
+		return nil, errors.New("asset: pixel data too long")
+	}
+	// Buffers grow as the data arrives: a forged length must not reserve
+	// gigabytes upfront
+	var err error
+	if a.Pix, err = readAssetData(br, int64(n)); err != nil {
+		return nil, err
+	}
+	if a.RLE && !validRLE565(a.Pix, a.Width*a.Height) {
+		return nil, errors.New("asset: corrupt compressed pixel data")
+	}
+	if hdr[5]&assetMask != 0 {
+		a.Mask = &Bitmap{Width: a.Width, Height: a.Height, Stride: (a.Width + 7) / 8}
+		if a.Mask.Bits, err = readAssetData(br, int64(a.Mask.Stride*a.Height)); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// readAssetData reads n bytes from r, growing the buffer as they arrive
+func readAssetData(r io.Reader, n int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, n); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Bounds returns the size of the asset.
+func (a *Asset) Bounds() image.Rectangle {
+	return image.Rect(0, 0, a.Width, a.Height)
+}
+
+// DrawAsset draws a with its top left corner at (x, y), clipped to the
+// display. The pixels are copied to the transfer buffer as stored, expanding
+// compressed runs on the way. Pixels outside the mask, if any, are left
+// untouched; each row is then sent as runs of opaque pixels.
+func (d *Device) DrawAsset(x, y int16, a *Asset) error {
+	vis := image.Rect(int(x), int(y), int(x)+a.Width, int(y)+a.Height).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+	left := vis.Min.X - int(x)
+	src := a.source(vis.Min.Y - int(y))
+
+	if a.Mask == nil {
+		return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+			src(left, dst)
+		})
+	}
+
+	line := make([]byte, vis.Dx()*2)
+	for py := vis.Min.Y; py < vis.Max.Y; py++ {
+		src(left, line)
+		my := py - int(y)
+		for i := 0; i < vis.Dx(); {
+			if !a.Mask.At(left+i, my) {
+				i++
+				continue
+			}
+			j := i + 1
+			for j < vis.Dx() && a.Mask.At(left+j, my) {
+				j++
+			}
+			d.setWindow(int16(vis.Min.X+i), int16(py), int16(j-i), 1)
+			if err := d.transport.WritePixels(line[i*2:j*2], 1); err != nil {
+				return err
+			}
+			i = j
+		}
+	}
+	return nil
+}
+
+// source returns a function copying the rows of a in order, from row y. Each
+// call copies the next row, from column x on, into dst, whose length sets how
+// many pixels are copied.
+func (a *Asset) source(y int) func(x int, dst []byte) {
+	if a.RLE {
+		return rleRows(a.Pix, a.Width, y)
+	}
+	return func(x int, dst []byte) {
+		i := (y*a.Width + x) * 2
+		copy(dst, a.Pix[i:i+len(dst)])
+		y++
+	}
+}
@@ -0,0 +1,102 @@
+package gc9307
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// assetImage is 4x2: three red pixels and a transparent one over blue and
+// three green.
+func assetImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 3; x++ {
+		img.Set(x, 0, color.NRGBA{255, 0, 0, 255})
+		img.Set(x+1, 1, color.NRGBA{0, 255, 0, 255})
+	}
+	img.Set(3, 0, color.NRGBA{255, 255, 255, 0})
+	img.Set(0, 1, color.NRGBA{0, 0, 255, 255})
+	return img
+}
+
+// assetHeader builds the 16 byte header of an asset container.
+func assetHeader(flags byte, w, h uint16, n uint32) []byte {
+	hdr := append([]byte(assetMagic), assetVersion, flags)
+	hdr = binary.BigEndian.AppendUint16(hdr, w)
+	hdr = binary.BigEndian.AppendUint16(hdr, h)
+	hdr = binary.BigEndian.AppendUint32(hdr, n)
+	return append(hdr, 0, 0)
+}
+
+func TestAssetRoundTrip(t *testing.T) {
+	red, green, blue := []byte{0x00, 0x1F}, []byte{0x07, 0xE0}, []byte{0xF8, 0x00}
+	var pix []byte
+	// The transparent pixel is premultiplied to black
+	for _, p := range [][]byte{red, red, red, {0, 0}, blue, green, green, green} {
+		pix = append(pix, p...)
+	}
+	for _, opts := range []AssetOptions{{}, {RLE: true}, {Alpha: true}, {RLE: true, Alpha: true}} {
+		var buf bytes.Buffer
+		if err := EncodeAsset(&buf, assetImage(), opts); err != nil {
+			t.Fatal(err)
+		}
+		a, err := DecodeAsset(&buf)
+		if err != nil {
+			t.Errorf("%+v: %v", opts, err)
+			continue
+		}
+		if a.Width != 4 || a.Height != 2 || a.RLE != opts.RLE || (a.Mask != nil) != opts.Alpha {
+			t.Errorf("%+v: decoded %dx%d, RLE %t, mask %t", opts, a.Width, a.Height, a.RLE, a.Mask != nil)
+			continue
+		}
+
+		d, r := recorderDevice(t)
+		if err := d.DrawAsset(0, 0, a); err != nil {
+			t.Fatal(err)
+		}
+		want := pix
+		if opts.Alpha {
+			// The transparent pixel is skipped
+			want = append(append([]byte{}, pix[:6]...), pix[8:]...)
+		}
+		if got := sentPixels(r); !bytes.Equal(got, want) {
+			t.Errorf("%+v: sent %x, want %x", opts, got, want)
+		}
+	}
+}
+
+func TestDecodeAssetErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeAsset(&buf, assetImage(), AssetOptions{Alpha: true}); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want error // nil for any error
+	}{
+		{"Empty", nil, io.ErrUnexpectedEOF},
+		{"TruncatedHeader", valid[:10], io.ErrUnexpectedEOF},
+		{"Magic", append([]byte("G566"), valid[4:]...), nil},
+		{"Version", append(append([]byte(assetMagic), 2), valid[5:]...), nil},
+		{"ZeroSize", assetHeader(0, 0, 2, 0), nil},
+		{"LengthMismatch", append(assetHeader(0, 4, 2, 14), make([]byte, 14)...), nil},
+		{"TruncatedPixels", valid[:20], io.ErrUnexpectedEOF},
+		{"TruncatedMask", valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{"RLETooLong", assetHeader(assetRLE, 4, 2, 25), nil},
+		{"CorruptRLE", append(assetHeader(assetRLE, 4, 2, 3), 0x80|8, 0, 0), nil},
+		// Headers claiming gigabytes that never come
+		{"ForgedLength", assetHeader(assetRLE, 0xFFFF, 0xFFFF, 0xFFFFFFFF), io.ErrUnexpectedEOF},
+		{"ForgedPixels", assetHeader(assetMask, 0x8000, 0x8000, 0x8000*0x8000*2), io.ErrUnexpectedEOF},
+	} {
+		_, err := DecodeAsset(bytes.NewReader(tc.data))
+		if err == nil || tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package gc9307
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// BDFFont is a Font read from a BDF (Glyph Bitmap Distribution Format) file.
+// Glyph encodings are taken as Unicode code points, as in ISO10646-1 fonts
+// such as GNU Unifont or WenQuanYi.
+//
+// Only the position of each character is indexed when the font is parsed;
+// bitmaps are decoded the first time a character is drawn.
+type BDFFont struct {
+	data    []byte
+	offsets map[rune]int // Offset of the line after ENCODING
+	ascent  int
+	descent int
+	advance int // Default DWIDTH
+	cache   glyphCache
+}
+
+// ParseBDF reads a BDF font.
+func ParseBDF(r io.Reader) (*BDFFont, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &BDFFont{data: data, offsets: map[rune]int{}}
+	f.cache.decode = f.decode
+
+	var (
+		bbox            []int
+		ascent, descent = -1, -1
+		started, inChar bool
+		encodingSet     bool
+	)
+	for pos := 0; pos < len(data); {
+		line, next := bdfLine(data, pos)
+		pos = next
+		key, args := bdfFields(line)
+		switch {
+		case key == "" || key == "COMMENT":
+		case key == "STARTFONT":
+			started = true
+		case !started:
+			return nil, errors.New("bdf: missing STARTFONT")
+		case key == "STARTCHAR":
+			inChar, encodingSet = true, false
+		case key == "ENDCHAR":
+			inChar = false
+		case inChar && key == "ENCODING" && !encodingSet:
+			encodingSet = true
+			if len(args) > 0 && args[0] >= 0 {
+				f.offsets[rune(args[0])] = pos
+			}
+		case inChar:
+			// Per character data is decoded lazily
+		case key == "FONTBOUNDINGBOX":
+			bbox = args
+		case key == "FONT_ASCENT" && len(args) > 0:
+			ascent = args[0]
+		case key == "FONT_DESCENT" && len(args) > 0:
+			descent = args[0]
+		case key == "DWIDTH" && len(args) > 0:
+			f.advance = args[0]
+		}
+	}
+	if !started {
+		return nil, errors.New("bdf: missing STARTFONT")
+	}
+	if len(bbox) < 4 && (ascent < 0 || descent < 0) {
+		return nil, errors.New("bdf: missing FONTBOUNDINGBOX")
+	}
+	if ascent < 0 {
+		ascent = bbox[1] + bbox[3]
+	}
+	if descent < 0 {
+		descent = -bbox[3]
+	}
+	if f.advance == 0 && len(bbox) >= 4 {
+		f.advance = bbox[0]
+	}
+	f.ascent, f.descent = ascent, descent
+	return f, nil
+}
+
+// Glyph implements Font.
+func (f *BDFFont) Glyph(r rune) (*Glyph, bool) {
+	return f.cache.get(r)
+}
+
+// Metrics implements Font.
+func (f *BDFFont) Metrics() (ascent, descent int) {
+	return f.ascent, f.descent
+}
+
+// Len returns the number of characters in the font.
+func (f *BDFFont) Len() int {
+	return len(f.offsets)
+}
+
+// decode parses the character of r, nil if missing or malformed
+func (f *BDFFont) decode(r rune) *Glyph {
+	pos, ok := f.offsets[r]
+	if !ok {
+		return nil
+	}
+	g := &Glyph{Advance: f.advance}
+	hasBBX := false
+	for pos < len(f.data) {
+		line, next := bdfLine(f.data, pos)
+		pos = next
+		key, args := bdfFields(line)
+		switch key {
+		case "DWIDTH":
+			if len(args) > 0 {
+				g.Advance = args[0]
+			}
+		case "BBX":
+			if len(args) < 4 || args[0] < 0 || args[1] < 0 || args[0] > 0xFFFF || args[1] > 0xFFFF {
+				return nil
+			}
+			g.Width, g.Height = args[0], args[1]
+			g.OffsetX, g.OffsetY = args[2], -(args[1] + args[3])
+			hasBBX = true
+		case "BITMAP":
+			if !hasBBX {
+				return nil
+			}
+			g.Stride = (g.Width + 7) / 8
+			// Each byte takes two hex digits: a BBX larger than what is
+			// left of the file is not allocated
+			if g.Stride*g.Height > (len(f.data)-pos)/2 {
+				return nil
+			}
+			g.Bits = make([]byte, g.Stride*g.Height)
+			for y := 0; y < g.Height && pos < len(f.data); y++ {
+				line, pos = bdfLine(f.data, pos)
+				row := bytes.TrimSpace(line)
+				for i := 0; i < g.Stride && 2*i+2 <= len(row); i++ {
+					v, err := strconv.ParseUint(string(row[2*i:2*i+2]), 16, 8)
+					if err != nil {
+						return nil
+					}
+					g.Bits[y*g.Stride+i] = uint8(v)
+				}
+			}
+			return g
+		case "ENDCHAR":
+			return nil
+		}
+	}
+	return nil
+}
+
+// bdfLine returns the line starting at pos and the offset of the next one
+func bdfLine(data []byte, pos int) (line []byte, next int) {
+	end := bytes.IndexByte(data[pos:], '\n')
+	if end < 0 {
+		return data[pos:], len(data)
+	}
+	return data[pos : pos+end], pos + end + 1
+}
+
+// bdfFields splits a line into its keyword and integer arguments. Non
+// numeric arguments, e.g. in properties, are ignored.
+func bdfFields(line []byte) (key string, args []int) {
+	fields := bytes.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	for _, a := range fields[1:] {
+		if v, err := strconv.Atoi(string(a)); err == nil {
+			args = append(args, v)
+		}
+	}
+	return string(fields[0]), args
+}
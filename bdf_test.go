@@ -0,0 +1,141 @@
+package gc9307
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// bdfHeader starts a font with a 4x6 bounding box, one pixel below the
+// baseline.
+const bdfHeader = `STARTFONT 2.1
+COMMENT test font
+FONT -test-fixed-medium-r-normal--6-60-75-75-c-40-iso10646-1
+SIZE 6 75 75
+FONTBOUNDINGBOX 4 6 0 -1
+`
+
+// bdfFont returns a font with the given properties and characters.
+func bdfFont(props, chars string) string {
+	return bdfHeader + "STARTPROPERTIES\n" + props + "ENDPROPERTIES\n" + chars + "ENDFONT\n"
+}
+
+const bdfChars = `CHARS 4
+STARTCHAR A
+ENCODING 65
+SWIDTH 500 0
+DWIDTH 5 0
+BBX 3 3 0 0
+BITMAP
+40
+A0
+E0
+ENDCHAR
+STARTCHAR Euro
+ENCODING 8364
+BBX 10 2 1 -1
+BITMAP
+FFC0
+8040
+ENDCHAR
+STARTCHAR unencoded
+ENCODING -1
+BBX 1 1 0 0
+BITMAP
+80
+ENDCHAR
+STARTCHAR space
+ENCODING 32 32
+BBX 0 0 0 0
+BITMAP
+ENDCHAR
+`
+
+func TestParseBDF(t *testing.T) {
+	f, err := ParseBDF(strings.NewReader(bdfFont("FONT_ASCENT 5\nFONT_DESCENT 2\n", bdfChars)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, d := f.Metrics(); a != 5 || d != 2 {
+		t.Errorf("Metrics() = %d, %d, want 5, 2", a, d)
+	}
+	if n := f.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+	for _, tc := range []struct {
+		r    rune
+		want Glyph
+	}{
+		{'A', Glyph{Width: 3, Height: 3, OffsetY: -3, Advance: 5, Stride: 1, Bits: []byte{0x40, 0xA0, 0xE0}}},
+		// The font's bounding box sets the default advance
+		{'€', Glyph{Width: 10, Height: 2, OffsetX: 1, OffsetY: -1, Advance: 4, Stride: 2, Bits: []byte{0xFF, 0xC0, 0x80, 0x40}}},
+		{' ', Glyph{Advance: 4, Bits: []byte{}}},
+	} {
+		g, ok := f.Glyph(tc.r)
+		if !ok {
+			t.Errorf("%q: no glyph", tc.r)
+			continue
+		}
+		if g.Width != tc.want.Width || g.Height != tc.want.Height || g.OffsetX != tc.want.OffsetX ||
+			g.OffsetY != tc.want.OffsetY || g.Advance != tc.want.Advance || g.Stride != tc.want.Stride ||
+			!bytes.Equal(g.Bits, tc.want.Bits) {
+			t.Errorf("%q: glyph %+v, want %+v", tc.r, *g, tc.want)
+		}
+	}
+	if _, ok := f.Glyph('B'); ok {
+		t.Error("got a glyph for a missing character")
+	}
+
+	// Without FONT_ASCENT and FONT_DESCENT, the bounding box sets them
+	f, err = ParseBDF(strings.NewReader(bdfFont("", bdfChars)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, d := f.Metrics(); a != 5 || d != 1 {
+		t.Errorf("Metrics() from the bounding box = %d, %d, want 5, 1", a, d)
+	}
+}
+
+func TestParseBDFErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+	}{
+		{"Empty", ""},
+		{"NoStartFont", "FONT x\nSTARTFONT 2.1\n"},
+		{"NoBoundingBox", "STARTFONT 2.1\nFONT_ASCENT 5\nENDFONT\n"},
+		{"ShortBoundingBox", "STARTFONT 2.1\nFONTBOUNDINGBOX 4 6\nFONT_ASCENT 5\nENDFONT\n"},
+	} {
+		if _, err := ParseBDF(strings.NewReader(tc.src)); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
+
+// Malformed characters are missing from the font, not errors.
+func TestBDFMalformedGlyphs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		char string
+	}{
+		{"NoBBX", "BITMAP\n80\nENDCHAR\n"},
+		{"ShortBBX", "BBX 1 1 0\nBITMAP\n80\nENDCHAR\n"},
+		{"NegativeBBX", "BBX -1 1 0 0\nBITMAP\n80\nENDCHAR\n"},
+		{"HugeBBX", "BBX 65535 65535 0 0\nBITMAP\n80\nENDCHAR\n"},
+		{"OverflowBBX", "BBX 9223372036854775807 1 0 0\nBITMAP\n80\nENDCHAR\n"},
+		{"NoBitmap", "BBX 1 1 0 0\nENDCHAR\n"},
+		{"InvalidHex", "BBX 8 2 0 0\nBITMAP\nZZ\n00\nENDCHAR\n"},
+		{"MissingRows", "BBX 8 3 0 0\nBITMAP\n80\nENDCHAR\n"},
+		{"Truncated", "BBX 8 3 0 0\nBITMAP\n80\n"},
+	} {
+		src := bdfHeader + "STARTCHAR x\nENCODING 120\n" + tc.char
+		f, err := ParseBDF(strings.NewReader(src))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if g, ok := f.Glyph('x'); ok {
+			t.Errorf("%s: got glyph %+v", tc.name, *g)
+		}
+	}
+}
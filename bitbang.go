@@ -0,0 +1,322 @@
+package gc9307
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"periph.io/x/conn/v3"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/conn/v3/spi"
+)
+
+// BitBang is a software SPI connection that drives SCK, MOSI, and optionally
+// MISO and CS, through GPIO pins. It can be passed to New in place of a
+// hardware spi.Conn, for bring-up or when the SPI controller is unusable.
+//
+// Words of up to 8 bits use one byte, words of 9 to 16 bits use two bytes in
+// little-endian order, like spidev.
+type BitBang struct {
+	mu         sync.Mutex
+	clk        gpio.PinOut
+	mosi       gpio.PinIO
+	miso       gpio.PinIn
+	cs         gpio.PinOut
+	mode       spi.Mode
+	bits       int
+	freq       physic.Frequency
+	halfPeriod time.Duration
+	csActive   bool // CS is asserted between TxPackets calls
+}
+
+// NewBitBang returns a software SPI connection on the given pins.
+//
+// miso may be nil for a write-only connection; with spi.HalfDuplex the data
+// is read back on mosi instead, as in the panel's 3-wire read mode. cs may be
+// nil when the panel's CS is tied low. A frequency of 0 toggles the pins as
+// fast as the GPIO driver allows. bits is the default word size, 1 to 16.
+func NewBitBang(clk gpio.PinOut, mosi gpio.PinIO, miso gpio.PinIn, cs gpio.PinOut, f physic.Frequency, mode spi.Mode, bits int) (*BitBang, error) {
+	if clk == nil || mosi == nil {
+		return nil, errors.New("bitbang: SCK and MOSI pins are required")
+	}
+	if bits < 1 || bits > 16 {
+		return nil, fmt.Errorf("bitbang: invalid word size %d", bits)
+	}
+	b := &BitBang{
+		clk:  clk,
+		mosi: mosi,
+		miso: miso,
+		cs:   cs,
+		mode: mode,
+		bits: bits,
+	}
+	b.setFreq(f)
+
+	if err := clk.Out(b.idle()); err != nil {
+		return nil, err
+	}
+	if err := mosi.Out(gpio.Low); err != nil {
+		return nil, err
+	}
+	if miso != nil {
+		if err := miso.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+			return nil, err
+		}
+	}
+	if b.useCS() {
+		if err := cs.Out(gpio.High); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// String implements conn.Conn.
+func (b *BitBang) String() string {
+	return fmt.Sprintf("BitBang(%s,%s,%s,%s)", pinName(b.clk), pinName(b.mosi), pinName(b.miso), pinName(b.cs))
+}
+
+// Duplex implements conn.Conn.
+func (b *BitBang) Duplex() conn.Duplex {
+	if b.mode&spi.HalfDuplex != 0 {
+		return conn.Half
+	}
+	return conn.Full
+}
+
+// Tx implements conn.Conn. It runs a single transaction with CS asserted.
+func (b *BitBang) Tx(w, r []byte) error {
+	return b.TxPackets([]spi.Packet{{W: w, R: r}})
+}
+
+// TxPackets implements spi.Conn.
+func (b *BitBang) TxPackets(p []spi.Packet) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range p {
+		if err := b.checkPacket(&p[i]); err != nil {
+			return err
+		}
+	}
+	for i := range p {
+		b.assertCS()
+		if err := b.txPacket(&p[i]); err != nil {
+			b.releaseCS()
+			return err
+		}
+		if !p[i].KeepCS {
+			b.releaseCS()
+		}
+	}
+	return nil
+}
+
+// LimitSpeed changes the clock frequency used for the following transfers.
+func (b *BitBang) LimitSpeed(f physic.Frequency) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setFreq(f)
+	return nil
+}
+
+// CLK implements spi.Pins.
+func (b *BitBang) CLK() gpio.PinOut {
+	return b.clk
+}
+
+// MOSI implements spi.Pins.
+func (b *BitBang) MOSI() gpio.PinOut {
+	return b.mosi
+}
+
+// MISO implements spi.Pins.
+func (b *BitBang) MISO() gpio.PinIn {
+	return b.miso
+}
+
+// CS implements spi.Pins.
+func (b *BitBang) CS() gpio.PinOut {
+	return b.cs
+}
+
+func (b *BitBang) setFreq(f physic.Frequency) {
+	b.freq = f
+	b.halfPeriod = 0
+	if f > 0 {
+		b.halfPeriod = f.Period() / 2
+	}
+}
+
+// idle returns the clock level between words, set by CPOL
+func (b *BitBang) idle() gpio.Level {
+	return b.mode&spi.Mode2 != 0
+}
+
+func (b *BitBang) useCS() bool {
+	return b.cs != nil && b.mode&spi.NoCS == 0
+}
+
+func (b *BitBang) assertCS() {
+	if b.useCS() && !b.csActive {
+		b.cs.Out(gpio.Low)
+		b.csActive = true
+		b.delay()
+	}
+}
+
+func (b *BitBang) releaseCS() {
+	if b.useCS() && b.csActive {
+		b.delay()
+		b.cs.Out(gpio.High)
+		b.csActive = false
+		b.delay()
+	}
+}
+
+// delay waits half a clock period. time.Sleep is far too coarse for this,
+// so spin instead.
+func (b *BitBang) delay() {
+	if b.halfPeriod <= 0 {
+		return
+	}
+	for start := time.Now(); time.Since(start) < b.halfPeriod; {
+	}
+}
+
+func (b *BitBang) checkPacket(p *spi.Packet) error {
+	bits := b.wordBits(p)
+	if bits < 1 || bits > 16 {
+		return fmt.Errorf("bitbang: invalid word size %d", bits)
+	}
+	half := b.mode&spi.HalfDuplex != 0
+	if half && len(p.W) != 0 && len(p.R) != 0 {
+		return errors.New("bitbang: can only write or read in half duplex mode")
+	}
+	if !half && len(p.W) != 0 && len(p.R) != 0 && len(p.W) != len(p.R) {
+		return errors.New("bitbang: write and read buffers must have the same length")
+	}
+	if len(p.R) != 0 && !half && b.miso == nil {
+		return errors.New("bitbang: reading requires a MISO pin or half duplex mode")
+	}
+	if n := wordBytes(bits); len(p.W)%n != 0 || len(p.R)%n != 0 {
+		return fmt.Errorf("bitbang: buffer length must be a multiple of %d", n)
+	}
+	return nil
+}
+
+func (b *BitBang) wordBits(p *spi.Packet) int {
+	if p.BitsPerWord != 0 {
+		return int(p.BitsPerWord)
+	}
+	return b.bits
+}
+
+// wordBytes returns the number of bytes holding one word
+func wordBytes(bits int) int {
+	if bits > 8 {
+		return 2
+	}
+	return 1
+}
+
+func (b *BitBang) txPacket(p *spi.Packet) error {
+	bits := b.wordBits(p)
+	size := wordBytes(bits)
+	n := len(p.W)
+	if len(p.R) > n {
+		n = len(p.R)
+	}
+
+	// In half duplex mode the data line is turned around for reads
+	in := b.miso
+	reading := len(p.R) != 0
+	if b.mode&spi.HalfDuplex != 0 {
+		in = b.mosi
+		if reading {
+			if err := b.mosi.In(gpio.PullNoChange, gpio.NoEdge); err != nil {
+				return err
+			}
+			defer b.mosi.Out(gpio.Low)
+		}
+	}
+
+	for i := 0; i < n; i += size {
+		var out uint16
+		if len(p.W) != 0 {
+			out = uint16(p.W[i])
+			if size == 2 {
+				out |= uint16(p.W[i+1]) << 8
+			}
+		}
+		v := b.word(out, bits, reading, in)
+		if reading {
+			p.R[i] = uint8(v)
+			if size == 2 {
+				p.R[i+1] = uint8(v >> 8)
+			}
+		}
+	}
+	return nil
+}
+
+// word clocks one word out on MOSI while sampling the input pin
+func (b *BitBang) word(out uint16, bits int, reading bool, in gpio.PinIn) uint16 {
+	idle := b.idle()
+	cpha := b.mode&spi.Mode1 != 0
+	lsbFirst := b.mode&spi.LSBFirst != 0
+	writing := !reading || b.mode&spi.HalfDuplex == 0
+
+	var v uint16
+	for i := 0; i < bits; i++ {
+		shift := uint(bits - 1 - i)
+		if lsbFirst {
+			shift = uint(i)
+		}
+		level := gpio.Level(out>>shift&1 != 0)
+
+		var sample gpio.Level
+		if !cpha {
+			// Data is set up before the leading edge and sampled on it
+			if writing {
+				b.mosi.Out(level)
+			}
+			b.delay()
+			b.clk.Out(!idle)
+			if reading {
+				sample = in.Read()
+			}
+			b.delay()
+			b.clk.Out(idle)
+		} else {
+			// Data changes on the leading edge and is sampled on the trailing one
+			b.clk.Out(!idle)
+			if writing {
+				b.mosi.Out(level)
+			}
+			b.delay()
+			b.clk.Out(idle)
+			if reading {
+				sample = in.Read()
+			}
+			b.delay()
+		}
+		if sample {
+			v |= 1 << shift
+		}
+	}
+	return v
+}
+
+// pinName returns the name of an optional pin
+func pinName(p interface{ Name() string }) string {
+	if p == nil {
+		return "NC"
+	}
+	return p.Name()
+}
+
+var _ spi.Conn = &BitBang{}
+var _ spi.Pins = &BitBang{}
@@ -0,0 +1,420 @@
+package gc9307
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/conn/v3/spi"
+)
+
+// event is one entry of the pin log shared by the fake pins of a bus.
+type event struct {
+	pin   string
+	what  byte // 'o' for Out, 'i' for In, 'r' for Read
+	level gpio.Level
+}
+
+func (e event) String() string {
+	switch e.what {
+	case 'i':
+		return e.pin + "in"
+	case 'r':
+		return e.pin + "?" + levelChar(e.level)
+	}
+	return e.pin + levelChar(e.level)
+}
+
+func levelChar(l gpio.Level) string {
+	if l {
+		return "1"
+	}
+	return "0"
+}
+
+// fakePin is a gpio.PinIO that logs its level changes, mode changes and
+// reads. Read returns the queued levels in turn, then Low.
+type fakePin struct {
+	name   string
+	log    *[]event
+	level  gpio.Level
+	input  bool
+	levels []gpio.Level
+}
+
+func (p *fakePin) String() string   { return p.name }
+func (p *fakePin) Halt() error      { return nil }
+func (p *fakePin) Name() string     { return p.name }
+func (p *fakePin) Number() int      { return -1 }
+func (p *fakePin) Function() string { return "" }
+
+func (p *fakePin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.input = true
+	*p.log = append(*p.log, event{p.name, 'i', false})
+	return nil
+}
+
+func (p *fakePin) Read() gpio.Level {
+	l := gpio.Low
+	if len(p.levels) != 0 {
+		l, p.levels = p.levels[0], p.levels[1:]
+	}
+	*p.log = append(*p.log, event{p.name, 'r', l})
+	return l
+}
+
+func (p *fakePin) WaitForEdge(timeout time.Duration) bool { return false }
+func (p *fakePin) Pull() gpio.Pull                        { return gpio.PullNoChange }
+func (p *fakePin) DefaultPull() gpio.Pull                 { return gpio.PullNoChange }
+
+// Out only logs changes, and any Out after In
+func (p *fakePin) Out(l gpio.Level) error {
+	if p.input || l != p.level {
+		*p.log = append(*p.log, event{p.name, 'o', l})
+	}
+	p.input, p.level = false, l
+	return nil
+}
+
+func (p *fakePin) PWM(duty gpio.Duty, f physic.Frequency) error {
+	return fmt.Errorf("%s: PWM not supported", p.name)
+}
+
+var _ gpio.PinIO = &fakePin{}
+
+// fakeWires are the pins of a bit-banged bus, logging to one shared log.
+type fakeWires struct {
+	log                 []event
+	clk, mosi, miso, cs *fakePin
+}
+
+func newFakeWires() *fakeWires {
+	w := &fakeWires{}
+	w.clk = &fakePin{name: "CLK", log: &w.log}
+	w.mosi = &fakePin{name: "MOSI", log: &w.log}
+	w.miso = &fakePin{name: "MISO", log: &w.log}
+	w.cs = &fakePin{name: "CS", log: &w.log}
+	return w
+}
+
+// trace returns the log as a space separated string
+func (w *fakeWires) trace() string {
+	s := make([]string, len(w.log))
+	for i, e := range w.log {
+		s[i] = e.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// analyze plays back the pin log as a device on the bus would see it, from
+// an idle bus. It returns the MOSI bits sampled in every CS assertion, with
+// x for the clocks where MOSI is an input, and reports clock edges outside
+// CS, CS changes with the clock active, MOSI changes too close to the
+// sampling edge, and reads away from it.
+func analyze(t *testing.T, log []event, mode spi.Mode) []string {
+	t.Helper()
+	idle := gpio.Level(mode&spi.Mode2 != 0)
+	cpha := mode&spi.Mode1 != 0
+	// MOSI may only change while the clock has this level: before the
+	// leading edge with CPHA=0, after it with CPHA=1
+	setup := gpio.Level(bool(idle) != cpha)
+
+	clk, cs := idle, gpio.High
+	mosiIn := false
+	sampled := false // The last clock edge was a sampling edge
+	var frames []string
+	var frame strings.Builder
+	for i, e := range log {
+		switch {
+		case e.pin == "CS":
+			if clk != idle {
+				t.Errorf("event %d: CS changed with the clock active", i)
+			}
+			cs = e.level
+			if cs == gpio.High {
+				frames = append(frames, frame.String())
+				frame.Reset()
+			}
+		case e.pin == "CLK":
+			if cs == gpio.High && mode&spi.NoCS == 0 {
+				t.Errorf("event %d: clock edge with CS released", i)
+			}
+			// The leading edge leaves the idle level; CPHA=0 samples on it
+			sampled = (e.level != idle) != cpha
+			clk = e.level
+			if sampled {
+				if mosiIn {
+					frame.WriteByte('x')
+				} else {
+					frame.WriteString(levelChar(mosiLevel(log[:i])))
+				}
+			}
+		case e.what == 'r':
+			if !sampled {
+				t.Errorf("event %d: %s read away from the sampling edge", i, e.pin)
+			}
+		case e.pin == "MOSI" && e.what == 'i':
+			mosiIn = true
+		case e.pin == "MOSI":
+			// Turning the line around after a read is not a data change
+			if !mosiIn && cs == gpio.Low && clk != setup {
+				t.Errorf("event %d: MOSI changed with the clock at %s", i, levelChar(clk))
+			}
+			mosiIn = false
+		}
+	}
+	if clk != idle {
+		t.Errorf("clock left at %s, idle is %s", levelChar(clk), levelChar(idle))
+	}
+	if frame.Len() != 0 {
+		frames = append(frames, frame.String())
+	}
+	return frames
+}
+
+// mosiLevel returns the last level driven on MOSI in log, Low by default
+func mosiLevel(log []event) gpio.Level {
+	for i := len(log) - 1; i >= 0; i-- {
+		if log[i].pin == "MOSI" && log[i].what == 'o' {
+			return log[i].level
+		}
+	}
+	return gpio.Low
+}
+
+// levels converts a string of 0s and 1s to levels
+func levels(s string) []gpio.Level {
+	l := make([]gpio.Level, len(s))
+	for i := range s {
+		l[i] = s[i] == '1'
+	}
+	return l
+}
+
+func TestBitBangWaveforms(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mode    spi.Mode
+		bits    int
+		packets []spi.Packet
+		in      string   // Levels read on MISO, or on MOSI in half duplex
+		want    []string // See analyze
+		read    []byte   // R of the last packet
+	}{
+		{
+			name:    "Mode0",
+			mode:    spi.Mode0,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0xA5, 0x3C}}},
+			want:    []string{"1010010100111100"},
+		},
+		{
+			name:    "Mode1",
+			mode:    spi.Mode1,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0xA5, 0x3C}}},
+			want:    []string{"1010010100111100"},
+		},
+		{
+			name:    "Mode2",
+			mode:    spi.Mode2,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0xA5, 0x3C}}},
+			want:    []string{"1010010100111100"},
+		},
+		{
+			name:    "Mode3",
+			mode:    spi.Mode3,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0xA5, 0x3C}}},
+			want:    []string{"1010010100111100"},
+		},
+		{
+			name:    "LSBFirst",
+			mode:    spi.Mode0 | spi.LSBFirst,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0xA1}}},
+			want:    []string{"10000101"},
+		},
+		{
+			// A command and a data byte as the 3-wire interface sends them,
+			// the D/C bit first, each word in two little-endian bytes
+			name:    "9Bit",
+			mode:    spi.Mode0,
+			bits:    9,
+			packets: []spi.Packet{{W: []byte{0x2A, 0x00, 0x22, 0x01}}},
+			want:    []string{"000101010" + "100100010"},
+		},
+		{
+			name:    "9BitMode3",
+			mode:    spi.Mode3,
+			bits:    9,
+			packets: []spi.Packet{{W: []byte{0xFF, 0x01}}},
+			want:    []string{"111111111"},
+		},
+		{
+			name:    "PacketWordSize",
+			mode:    spi.Mode0,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0x03, 0x00}, BitsPerWord: 9}, {W: []byte{0x81}}},
+			want:    []string{"000000011", "10000001"},
+		},
+		{
+			name:    "ReleaseCS",
+			mode:    spi.Mode0,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0x04}}, {W: []byte{0xF0}}},
+			want:    []string{"00000100", "11110000"},
+		},
+		{
+			name:    "KeepCS",
+			mode:    spi.Mode0,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0x04}, KeepCS: true}, {W: []byte{0xF0}}},
+			want:    []string{"00000100" + "11110000"},
+		},
+		{
+			name:    "NoCS",
+			mode:    spi.Mode0 | spi.NoCS,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0x04}}, {W: []byte{0xF0}}},
+			want:    []string{"0000010011110000"},
+		},
+		{
+			name:    "FullDuplexRead",
+			mode:    spi.Mode0,
+			bits:    8,
+			packets: []spi.Packet{{W: []byte{0x04, 0x00}, R: make([]byte, 2)}},
+			in:      "00000000" + "10000101",
+			want:    []string{"0000010000000000"},
+			read:    []byte{0x00, 0x85},
+		},
+		{
+			// RDDID on the 3-wire interface: the command, then the line
+			// turned around for the reply with CS held
+			name: "HalfDuplexRead",
+			mode: spi.Mode0 | spi.HalfDuplex,
+			bits: 8,
+			packets: []spi.Packet{
+				{W: []byte{0x04}, KeepCS: true},
+				{R: make([]byte, 2)},
+			},
+			in:   "11000010" + "01111110",
+			want: []string{"00000100" + "xxxxxxxx" + "xxxxxxxx"},
+			read: []byte{0xC2, 0x7E},
+		},
+		{
+			name: "HalfDuplexReadMode3",
+			mode: spi.Mode3 | spi.HalfDuplex,
+			bits: 9,
+			packets: []spi.Packet{
+				{W: []byte{0x04, 0x00}, KeepCS: true},
+				{R: make([]byte, 2)},
+			},
+			in:   "100000001",
+			want: []string{"000000100" + "xxxxxxxxx"},
+			read: []byte{0x01, 0x01},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newFakeWires()
+			half := tc.mode&spi.HalfDuplex != 0
+			var miso gpio.PinIn
+			in := w.mosi
+			if !half {
+				miso, in = w.miso, w.miso
+			}
+			b, err := NewBitBang(w.clk, w.mosi, miso, w.cs, 0, tc.mode, tc.bits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if idle := gpio.Level(tc.mode&spi.Mode2 != 0); w.clk.level != idle {
+				t.Errorf("after NewBitBang CLK %s, want %s", levelChar(w.clk.level), levelChar(idle))
+			}
+			if tc.mode&spi.NoCS == 0 && w.cs.level != gpio.High {
+				t.Error("after NewBitBang CS asserted")
+			}
+			if !half && !w.miso.input {
+				t.Error("MISO is not an input")
+			}
+			w.log = nil
+			in.levels = levels(tc.in)
+
+			if err := b.TxPackets(tc.packets); err != nil {
+				t.Fatal(err)
+			}
+			if got := analyze(t, w.log, tc.mode); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("sent %q, want %q", got, tc.want)
+			}
+			if tc.read != nil {
+				if got := tc.packets[len(tc.packets)-1].R; !bytes.Equal(got, tc.read) {
+					t.Errorf("read %#x, want %#x", got, tc.read)
+				}
+			}
+			if w.cs.level != gpio.High && tc.mode&spi.NoCS == 0 {
+				t.Error("CS left asserted")
+			}
+			if w.mosi.input {
+				t.Error("MOSI left as an input")
+			}
+		})
+	}
+}
+
+// The exact order of the pin changes for a short Mode0 word.
+func TestBitBangTrace(t *testing.T) {
+	w := newFakeWires()
+	b, err := NewBitBang(w.clk, w.mosi, nil, w.cs, 0, spi.Mode0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.log = nil
+	if err := b.Tx([]byte{0x05}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "CS0 MOSI1 CLK1 CLK0 MOSI0 CLK1 CLK0 MOSI1 CLK1 CLK0 CS1"
+	if got := w.trace(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestBitBangErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mode    spi.Mode
+		miso    bool
+		packets []spi.Packet
+	}{
+		{"HalfDuplexWriteAndRead", spi.Mode0 | spi.HalfDuplex, false, []spi.Packet{{W: []byte{1}, R: []byte{0}}}},
+		{"LengthMismatch", spi.Mode0, true, []spi.Packet{{W: []byte{1, 2}, R: []byte{0}}}},
+		{"ReadWithoutMISO", spi.Mode0, false, []spi.Packet{{R: []byte{0}}}},
+		{"OddLength9Bit", spi.Mode0, false, []spi.Packet{{W: []byte{1}, BitsPerWord: 9}}},
+		{"WordSize", spi.Mode0, false, []spi.Packet{{W: []byte{1, 0}, BitsPerWord: 17}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newFakeWires()
+			var miso gpio.PinIn
+			if tc.miso {
+				miso = w.miso
+			}
+			b, err := NewBitBang(w.clk, w.mosi, miso, w.cs, 0, tc.mode, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.log = nil
+			// Packets are checked before any of them is sent
+			packets := append([]spi.Packet{{W: []byte{0xFF}}}, tc.packets...)
+			if err := b.TxPackets(packets); err == nil {
+				t.Error("no error")
+			}
+			if len(w.log) != 0 {
+				t.Errorf("pins changed: %s", w.trace())
+			}
+		})
+	}
+}
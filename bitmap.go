@@ -0,0 +1,190 @@
+package gc9307
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// Bitmap is a packed monochrome image, one bit per pixel. Rows start on a
+// byte boundary, with the most significant bit leftmost.
+type Bitmap struct {
+	Width, Height int
+	Stride        int // Bytes per row
+	Bits          []byte
+}
+
+// NewBitmap returns a cleared bitmap.
+func NewBitmap(width, height int) *Bitmap {
+	stride := (width + 7) / 8
+	return &Bitmap{
+		Width:  width,
+		Height: height,
+		Stride: stride,
+		Bits:   make([]byte, stride*height),
+	}
+}
+
+// At reports whether the pixel at (x, y) is set.
+func (b *Bitmap) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return false
+	}
+	return b.Bits[y*b.Stride+x/8]&(0x80>>(x%8)) != 0
+}
+
+// Set sets or clears the pixel at (x, y).
+func (b *Bitmap) Set(x, y int, on bool) {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return
+	}
+	if on {
+		b.Bits[y*b.Stride+x/8] |= 0x80 >> (x % 8)
+	} else {
+		b.Bits[y*b.Stride+x/8] &^= 0x80 >> (x % 8)
+	}
+}
+
+// ParseXBM reads an X bitmap, the C source format of X11 cursors and icons,
+// in either the X11 (char array) or X10 (short array) flavor.
+func ParseXBM(r io.Reader) (*Bitmap, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dimensions come from the "#define <name>_width <n>" lines
+	width, height := -1, -1
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		f := bytes.Fields(line)
+		if len(f) != 3 || string(f[0]) != "#define" {
+			continue
+		}
+		v, err := strconv.Atoi(string(f[2]))
+		if err != nil {
+			continue
+		}
+		switch {
+		case bytes.HasSuffix(f[1], []byte("width")):
+			width = v
+		case bytes.HasSuffix(f[1], []byte("height")):
+			height = v
+		}
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("xbm: missing width or height")
+	}
+	if width > 0xFFFF || height > 0xFFFF {
+		return nil, errors.New("xbm: invalid image size")
+	}
+
+	start := bytes.IndexByte(data, '{')
+	end := bytes.LastIndexByte(data, '}')
+	if start < 0 || end < start {
+		return nil, errors.New("xbm: missing bits")
+	}
+	unit := 1
+	if bytes.Contains(data[:start], []byte("short")) {
+		unit = 2
+	}
+
+	// Pixels are stored least significant bit first, rows padded to a unit
+	var raw []byte
+	for _, v := range bytes.Split(data[start+1:end], []byte(",")) {
+		v = bytes.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(string(v), 0, 8*unit)
+		if err != nil {
+			return nil, errors.New("xbm: invalid value " + strconv.Quote(string(v)))
+		}
+		raw = append(raw, uint8(n))
+		if unit == 2 {
+			raw = append(raw, uint8(n>>8))
+		}
+	}
+	srcStride := (width + 8*unit - 1) / (8 * unit) * unit
+	if len(raw) < srcStride*height {
+		return nil, errors.New("xbm: not enough data")
+	}
+
+	b := NewBitmap(width, height)
+	for y := 0; y < height; y++ {
+		for i := 0; i < b.Stride; i++ {
+			b.Bits[y*b.Stride+i] = reverseBits(raw[y*srcStride+i])
+		}
+	}
+	return b, nil
+}
+
+// DrawBitmap draws bmp with its top left corner at (x, y), set pixels in fg
+// and clear ones in bg, clipped to the display. The bits are expanded to
+// RGB565 directly in the transfer buffer, through a single address window.
+// A bg with A == 0 draws only the set pixels, like DrawBitmapTransparent.
+func (d *Device) DrawBitmap(x, y int16, bmp *Bitmap, fg, bg color.RGBA) error {
+	return d.drawBitmap(int(x), int(y), bmp, fg, bg, d.bounds())
+}
+
+// DrawBitmapTransparent draws the set pixels of bmp in c, clipped to the
+// display, leaving the others untouched. The pixels are decomposed into
+// horizontal spans, and rows with identical spans into rectangles.
+func (d *Device) DrawBitmapTransparent(x, y int16, bmp *Bitmap, c color.RGBA) {
+	vis := image.Rect(int(x), int(y), int(x)+bmp.Width, int(y)+bmp.Height).Intersect(d.bounds())
+	d.drawBitmapSpans(int(x), int(y), bmp, vis, c)
+}
+
+// bounds returns the display area in the current orientation
+func (d *Device) bounds() image.Rectangle {
+	w, h := d.Size()
+	return image.Rect(0, 0, int(w), int(h))
+}
+
+// drawBitmap draws bmp at (x, y), clipped to clip, which must be inside the
+// display
+func (d *Device) drawBitmap(x, y int, bmp *Bitmap, fg, bg color.RGBA, clip image.Rectangle) error {
+	vis := image.Rect(x, y, x+bmp.Width, y+bmp.Height).Intersect(clip)
+	if vis.Empty() {
+		return nil
+	}
+	if bg.A == 0 {
+		d.drawBitmapSpans(x, y, bmp, vis, fg)
+		return nil
+	}
+
+	fg565, bg565 := RGBATo565BGR(fg), RGBATo565BGR(bg)
+	return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+		by := vis.Min.Y - y + row
+		for i := 0; i < vis.Dx(); i++ {
+			c := bg565
+			if bmp.At(vis.Min.X-x+i, by) {
+				c = fg565
+			}
+			put565(dst[i*2:], c)
+		}
+	})
+}
+
+// drawBitmapSpans draws the set pixels of bmp at (x, y) inside vis as spans
+func (d *Device) drawBitmapSpans(x, y int, bmp *Bitmap, vis image.Rectangle, c color.RGBA) {
+	m := rectMerger{d: d, c: c}
+	var spans []span
+	for py := vis.Min.Y; py < vis.Max.Y; py++ {
+		spans = spans[:0]
+		for px := vis.Min.X; px < vis.Max.X; px++ {
+			if !bmp.At(px-x, py-y) {
+				continue
+			}
+			if n := len(spans); n > 0 && spans[n-1].x1 == px-1 {
+				spans[n-1].x1++
+			} else {
+				spans = append(spans, span{px, px})
+			}
+		}
+		m.row(py, spans)
+	}
+	m.flush()
+}
@@ -0,0 +1,104 @@
+package gc9307
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestParseXBM(t *testing.T) {
+	// A 10x2 bitmap: the first row has pixels 0, 2 and 9 set, the second
+	// all of pixels 0 to 8
+	want := []byte{0xA0, 0x40, 0xFF, 0x80}
+	for _, tc := range []struct {
+		name string
+		src  string
+	}{
+		{"X11", `#define test_width 10
+#define test_height 2
+static unsigned char test_bits[] = {
+   0x05, 0x02, 0xff, 0x01 };
+`},
+		{"X10", `#define test_width 10
+#define test_height 2
+static unsigned short test_bits[] = {
+   0x0205, 0x01ff };
+`},
+		{"HotSpotAndDecimal", `#define test_width 10
+#define test_height 2
+#define test_x_hot 1
+#define test_y_hot 1
+static char test_bits[] = {5,2,
+	255,1,};
+`},
+	} {
+		b, err := ParseXBM(strings.NewReader(tc.src))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if b.Width != 10 || b.Height != 2 || !bytes.Equal(b.Bits, want) {
+			t.Errorf("%s: %dx%d bits %#x, want 10x2 %#x", tc.name, b.Width, b.Height, b.Bits, want)
+		}
+	}
+}
+
+func TestParseXBMErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+	}{
+		{"Empty", ""},
+		{"MissingHeight", "#define a_width 8\nstatic char a_bits[] = { 0x01 };"},
+		{"ZeroWidth", "#define a_width 0\n#define a_height 1\nstatic char a_bits[] = { 0x01 };"},
+		{"HugeWidth", "#define a_width 9223372036854775807\n#define a_height 2\nstatic char a_bits[] = { 0x01 };"},
+		{"MissingBits", "#define a_width 8\n#define a_height 1\n"},
+		{"Unclosed", "#define a_width 8\n#define a_height 1\nstatic char a_bits[] = { 0x01,"},
+		{"InvalidValue", "#define a_width 8\n#define a_height 1\nstatic char a_bits[] = { 0xzz };"},
+		{"ByteOverflow", "#define a_width 8\n#define a_height 1\nstatic char a_bits[] = { 0x100 };"},
+		{"NotEnoughData", "#define a_width 8\n#define a_height 2\nstatic char a_bits[] = { 0x01 };"},
+		// Shorts pad rows to 16 pixels
+		{"NotEnoughShorts", "#define a_width 17\n#define a_height 1\nstatic short a_bits[] = { 0x0101 };"},
+	} {
+		if _, err := ParseXBM(strings.NewReader(tc.src)); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
+
+func TestDrawBitmap(t *testing.T) {
+	b := NewBitmap(3, 2)
+	b.Set(0, 0, true)
+	b.Set(2, 1, true)
+	fg, bg := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	red, blue := []byte{0x00, 0x1F}, []byte{0xF8, 0x00}
+
+	d, r := recorderDevice(t)
+	if err := d.DrawBitmap(0, 0, b, fg, bg); err != nil {
+		t.Fatal(err)
+	}
+	want := bytes.Join([][]byte{red, blue, blue, blue, blue, red}, nil)
+	if got := sentPixels(r); !bytes.Equal(got, want) {
+		t.Errorf("opaque: sent %x, want %x", got, want)
+	}
+
+	// Clipped on the left, the first column is not read
+	r.Reset()
+	if err := d.DrawBitmap(-1, 0, b, fg, bg); err != nil {
+		t.Fatal(err)
+	}
+	want = bytes.Join([][]byte{blue, blue, blue, red}, nil)
+	if got := sentPixels(r); !bytes.Equal(got, want) {
+		t.Errorf("clipped: sent %x, want %x", got, want)
+	}
+
+	// Without a background only the set pixels are sent
+	r.Reset()
+	if err := d.DrawBitmap(0, 0, b, fg, color.RGBA{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sentPixels(r); !bytes.Equal(got, bytes.Repeat(red, 2)) {
+		t.Errorf("transparent: sent %x, want two red pixels", got)
+	}
+}
@@ -0,0 +1,59 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+)
+
+// blitRows streams a w x h rectangle at (x, y) to the display. fill is called
+// for every row, top to bottom, and writes the row's w pixels as big endian
+// RGB565 into dst. Rows are packed into the transfer buffer so each batch
+// carries as many whole rows as fit.
+func (d *Device) blitRows(x, y, w, h int16, fill func(row int, dst []byte)) error {
+	sw, sh := d.Size()
+	if x < 0 || y < 0 || w <= 0 || h <= 0 || x+w > sw || y+h > sh {
+		return errors.New("rectangle coordinates outside display area")
+	}
+
+	buf := d.buffer
+	if d.useDMA && len(d.dmaBuffer) > len(buf) {
+		buf = d.dmaBuffer
+	}
+	rowBytes := int(w) * 2
+	perBatch := len(buf) / rowBytes
+
+	d.setWindow(x, y, w, h)
+	d.BeginTransaction()
+	defer d.EndTransaction()
+	n := 0
+	for row := 0; row < int(h); row++ {
+		fill(row, buf[n*rowBytes:(n+1)*rowBytes])
+		n++
+		if n == perBatch || row == int(h)-1 {
+			if err := d.transport.WritePixels(buf[:n*rowBytes], 1); err != nil {
+				return err
+			}
+			n = 0
+		}
+	}
+	return nil
+}
+
+// put565 stores c as big endian RGB565 at the start of dst
+func put565(dst []byte, c uint16) {
+	dst[0] = uint8(c >> 8)
+	dst[1] = uint8(c)
+}
+
+// drawRGB565 draws a w x h block of big endian RGB565 pixels at (x, y),
+// clipped to the display
+func (d *Device) drawRGB565(x, y, w, h int, pix []byte) error {
+	vis := image.Rect(x, y, x+w, y+h).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+	return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+		i := ((vis.Min.Y-y+row)*w + vis.Min.X - x) * 2
+		copy(dst, pix[i:i+vis.Dx()*2])
+	})
+}
@@ -0,0 +1,232 @@
+package gc9307
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"strings"
+)
+
+// 16 bit BMP files (RGB565, BGR565 or 555 bitfields) are registered with
+// image.Decode. The magic matches the bit count in the info header, so other
+// BMP depths are left to decoders such as golang.org/x/image/bmp.
+func init() {
+	image.RegisterFormat("bmp", "BM"+strings.Repeat("?", 26)+"\x10\x00", DecodeBMP, DecodeBMPConfig)
+}
+
+// bmpInfo is what the headers of a 16 bit BMP file say about its pixels
+type bmpInfo struct {
+	width, height int
+	topDown       bool
+	masks         [3]uint32 // Red, green and blue
+}
+
+// DecodeBMPConfig returns the size of a 16 bit BMP image without decoding it.
+func DecodeBMPConfig(r io.Reader) (image.Config, error) {
+	info, err := readBMPHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: info.width, Height: info.height}, nil
+}
+
+// DecodeBMP reads a 16 bit BMP image as an *RGB565Image. Alpha bits, if
+// any, are ignored.
+func DecodeBMP(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	info, err := readBMPHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	img := NewRGB565Image(image.Rect(0, 0, info.width, info.height))
+	line := make([]byte, info.stride())
+	for i := 0; i < info.height; i++ {
+		if _, err := io.ReadFull(br, line); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		y := info.row(i)
+		info.convert(img.Pix[y*img.Stride:(y+1)*img.Stride], line)
+	}
+	return img, nil
+}
+
+// DrawBMP streams a 16 bit BMP image from r to the display with its top
+// left corner at (x, y), clipped to the display, without decoding it into
+// memory first: rows are converted straight into the transfer buffer.
+// RGB565 and BGR565 files only need their bytes reordered, no color
+// conversion.
+//
+// BMP files usually store the bottom row first; those are sent in bands of
+// as many rows as fit in the transfer buffer, from the bottom up. If the
+// file ends early, the rows of the band being read are not sent.
+func (d *Device) DrawBMP(x, y int16, r io.Reader) error {
+	br := bufio.NewReader(r)
+	info, err := readBMPHeader(br)
+	if err != nil {
+		return err
+	}
+	vis := image.Rect(int(x), int(y), int(x)+info.width, int(y)+info.height).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+	left := vis.Min.X - int(x)
+	line := make([]byte, info.stride())
+	stored := 0 // Rows read from the file
+	// next reads the next stored row within the visible area into dst
+	next := func(dst []byte) error {
+		for {
+			if _, err := io.ReadFull(br, line); err != nil {
+				return unexpectedEOF(err)
+			}
+			py := int(y) + info.row(stored)
+			stored++
+			if py >= vis.Min.Y && py < vis.Max.Y {
+				info.convert(dst, line[left*2:])
+				return nil
+			}
+		}
+	}
+
+	// Rows are read into bands of as many as fit in the transfer buffer,
+	// and a band is only sent once all its rows were read: a file cut short
+	// stops the transfer without sending stale rows.
+	buf := d.buffer
+	if d.useDMA && len(d.dmaBuffer) > len(buf) {
+		buf = d.dmaBuffer
+	}
+	rowBytes := vis.Dx() * 2
+	perBand := len(buf) / rowBytes
+	d.BeginTransaction()
+	defer d.EndTransaction()
+	for done := 0; done < vis.Dy(); {
+		n := perBand
+		if vis.Dy()-done < n {
+			n = vis.Dy() - done
+		}
+		// Bottom up files fill each band from its last row
+		top := vis.Max.Y - done - n
+		for k := 0; k < n; k++ {
+			i := n - 1 - k
+			if info.topDown {
+				i = k
+			}
+			if err := next(buf[i*rowBytes : (i+1)*rowBytes]); err != nil {
+				return err
+			}
+		}
+		if info.topDown {
+			top = vis.Min.Y + done
+		}
+		d.setWindow(int16(vis.Min.X), int16(top), int16(vis.Dx()), int16(n))
+		if err := d.transport.WritePixels(buf[:n*rowBytes], 1); err != nil {
+			return err
+		}
+		done += n
+	}
+	return nil
+}
+
+// readBMPHeader reads the headers of a 16 bit BMP file, leaving r at the
+// first pixel
+func readBMPHeader(r io.Reader) (bmpInfo, error) {
+	var info bmpInfo
+	var fh [18]byte // File header and the size of the info header
+	if _, err := io.ReadFull(r, fh[:]); err != nil {
+		return info, unexpectedEOF(err)
+	}
+	if fh[0] != 'B' || fh[1] != 'M' {
+		return info, errors.New("bmp: invalid magic")
+	}
+	offset := binary.LittleEndian.Uint32(fh[10:])
+	size := binary.LittleEndian.Uint32(fh[14:])
+	if size < 40 || offset < 14+size || offset > 1<<16 {
+		return info, errors.New("bmp: unsupported header")
+	}
+	// The info header, the bitfield masks following it and the palette
+	hdr := make([]byte, offset-18)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return info, unexpectedEOF(err)
+	}
+
+	w := int32(binary.LittleEndian.Uint32(hdr[0:]))
+	h := int32(binary.LittleEndian.Uint32(hdr[4:]))
+	bpp := binary.LittleEndian.Uint16(hdr[10:])
+	compression := binary.LittleEndian.Uint32(hdr[12:])
+	if bpp != 16 {
+		return info, errors.New("bmp: only 16 bit images are supported")
+	}
+	if h < 0 {
+		info.topDown, h = true, -h
+	}
+	if w <= 0 || h <= 0 || w > 0xFFFF || h > 0xFFFF {
+		return info, errors.New("bmp: invalid image size")
+	}
+	info.width, info.height = int(w), int(h)
+
+	switch compression {
+	case 0: // BI_RGB is 555
+		info.masks = [3]uint32{0x7C00, 0x03E0, 0x001F}
+	case 3, 6: // BI_BITFIELDS, BI_ALPHABITFIELDS
+		// In V2 and later headers, or right after a V1 header
+		if len(hdr) < 36+12 {
+			return info, errors.New("bmp: missing bitfield masks")
+		}
+		for i := range info.masks {
+			info.masks[i] = binary.LittleEndian.Uint32(hdr[36+i*4:])
+		}
+	default:
+		return info, errors.New("bmp: unsupported compression")
+	}
+	for _, m := range info.masks {
+		if m == 0 || m > 0xFFFF || bits.OnesCount32(m>>bits.TrailingZeros32(m)+1) != 1 {
+			return info, errors.New("bmp: invalid bitfield masks")
+		}
+	}
+	return info, nil
+}
+
+// stride returns the bytes per row in the file, padded to 4 bytes
+func (b *bmpInfo) stride() int {
+	return (b.width*2 + 3) &^ 3
+}
+
+// row returns the image row of the i-th row stored in the file
+func (b *bmpInfo) row(i int) int {
+	if b.topDown {
+		return i
+	}
+	return b.height - 1 - i
+}
+
+// convert writes the little endian pixels of src to dst as big endian
+// RGB565 in the panel's order, as many as dst holds
+func (b *bmpInfo) convert(dst, src []byte) {
+	n := len(dst) / 2
+	switch b.masks {
+	case [3]uint32{0x001F, 0x07E0, 0xF800}: // BGR565 is the panel's own order
+		for i := 0; i < n; i++ {
+			dst[i*2], dst[i*2+1] = src[i*2+1], src[i*2]
+		}
+	case [3]uint32{0xF800, 0x07E0, 0x001F}: // RGB565, swap red and blue
+		for i := 0; i < n; i++ {
+			v := uint16(src[i*2]) | uint16(src[i*2+1])<<8
+			put565(dst[i*2:], v&0x07E0|v>>11|v<<11)
+		}
+	default:
+		for i := 0; i < n; i++ {
+			v := uint32(src[i*2]) | uint32(src[i*2+1])<<8
+			put565(dst[i*2:], bgr565(bmpChannel(v, b.masks[0]), bmpChannel(v, b.masks[1]), bmpChannel(v, b.masks[2])))
+		}
+	}
+}
+
+// bmpChannel extracts the channel under mask from v, scaled to 8 bits
+func bmpChannel(v, mask uint32) uint8 {
+	shift := bits.TrailingZeros32(mask)
+	max := mask >> shift
+	return uint8((v & mask >> shift) * 255 / max)
+}
@@ -0,0 +1,201 @@
+package gc9307
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"testing"
+)
+
+// Bitfield masks of the 16 bit layouts
+var (
+	bmpRGB565 = []uint32{0xF800, 0x07E0, 0x001F}
+	bmpBGR565 = []uint32{0x001F, 0x07E0, 0xF800}
+)
+
+// bmpFile builds a 16 bit BMP file of w x h pixels, stored top-down when h
+// is negative. Masks, if any, follow a BITMAPINFOHEADER with BI_BITFIELDS.
+// rows holds the stored rows, in file order, padded by bmpFile.
+func bmpFile(w, h int, masks []uint32, rows [][]uint16) []byte {
+	le := binary.LittleEndian
+	offset := 14 + 40 + 4*len(masks)
+	f := []byte("BM")
+	f = le.AppendUint32(f, 0) // File size, not checked
+	f = le.AppendUint32(f, 0)
+	f = le.AppendUint32(f, uint32(offset))
+	f = le.AppendUint32(f, 40)
+	f = le.AppendUint32(f, uint32(int32(w)))
+	f = le.AppendUint32(f, uint32(int32(h)))
+	f = le.AppendUint16(f, 1)
+	f = le.AppendUint16(f, 16)
+	compression := uint32(0)
+	if masks != nil {
+		compression = 3
+	}
+	f = le.AppendUint32(f, compression)
+	f = append(f, make([]byte, 20)...)
+	for _, m := range masks {
+		f = le.AppendUint32(f, m)
+	}
+	for _, row := range rows {
+		for _, v := range row {
+			f = le.AppendUint16(f, v)
+		}
+		if len(row)%2 != 0 {
+			f = append(f, 0, 0)
+		}
+	}
+	return f
+}
+
+// bmpRows returns h rows of w pixels, each the value row<<8 | column.
+func bmpRows(w, h int) [][]uint16 {
+	rows := make([][]uint16, h)
+	for i := range rows {
+		rows[i] = make([]uint16, w)
+		for j := range rows[i] {
+			rows[i][j] = uint16(i<<8 | j)
+		}
+	}
+	return rows
+}
+
+func TestDecodeBMP(t *testing.T) {
+	red := []byte{0x00, 0x1F}
+	for _, tc := range []struct {
+		name  string
+		masks []uint32
+		v     uint16
+	}{
+		{"RGB565", bmpRGB565, 0xF800},
+		{"BGR565", bmpBGR565, 0x001F},
+		{"RGB555", nil, 0x7C00},
+		{"ARGB1555", []uint32{0x7C00, 0x03E0, 0x001F}, 0xFC00},
+	} {
+		// Bottom-up, with a white pixel in the bottom left corner
+		img, err := DecodeBMP(bytes.NewReader(bmpFile(3, 2, tc.masks, [][]uint16{{0xFFFF, tc.v, tc.v}, {tc.v, tc.v, tc.v}})))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		pix := img.(*RGB565Image).Pix
+		if !bytes.Equal(pix[:2], red) || !bytes.Equal(pix[8:10], red) {
+			t.Errorf("%s: pixels %x, want red %x", tc.name, pix, red)
+		}
+		if pix[6] == 0 || pix[7] == 0 {
+			t.Errorf("%s: bottom left pixel %x, want white", tc.name, pix[6:8])
+		}
+	}
+
+	f := bmpFile(2, -2, bmpBGR565, [][]uint16{{1, 2}, {3, 4}})
+	img, format, err := image.Decode(bytes.NewReader(f))
+	if err != nil || format != "bmp" {
+		t.Fatalf("image.Decode() = %q, %v", format, err)
+	}
+	if pix := img.(*RGB565Image).Pix; !bytes.Equal(pix, []byte{0, 1, 0, 2, 0, 3, 0, 4}) {
+		t.Errorf("top-down pixels %x", pix)
+	}
+}
+
+func TestDecodeBMPErrors(t *testing.T) {
+	valid := bmpFile(2, 2, bmpRGB565, bmpRows(2, 2))
+	eightBit := append([]byte{}, valid...)
+	eightBit[28] = 8
+	rle := bmpFile(2, 2, nil, bmpRows(2, 2))
+	rle[30] = 1
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want error // nil for any error
+	}{
+		{"Empty", nil, io.ErrUnexpectedEOF},
+		{"Magic", append([]byte("MB"), valid[2:]...), nil},
+		{"TruncatedHeader", valid[:30], io.ErrUnexpectedEOF},
+		{"TruncatedPixels", valid[:len(valid)-1], io.ErrUnexpectedEOF},
+		{"EightBit", eightBit, nil},
+		{"Compressed", rle, nil},
+		{"ZeroWidth", bmpFile(0, 2, bmpRGB565, nil), nil},
+		{"MissingMasks", bmpFile(2, 2, bmpRGB565[:1], nil), nil},
+		{"SplitMask", bmpFile(2, 2, []uint32{0xF00F, 0x07E0, 0x001F}, nil), nil},
+		{"ZeroMask", bmpFile(2, 2, []uint32{0, 0x07E0, 0x001F}, nil), nil},
+	} {
+		_, err := DecodeBMP(bytes.NewReader(tc.data))
+		if err == nil || tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestDrawBMP(t *testing.T) {
+	// 100 pixel rows, three to a band of the 640 byte transfer buffer
+	const w, h = 100, 4
+	rows := bmpRows(w, h)
+	// want returns the sent rows, by their stored index
+	want := func(stored ...int) []byte {
+		var pix []byte
+		for _, i := range stored {
+			for _, v := range rows[i] {
+				pix = append(pix, uint8(v>>8), uint8(v))
+			}
+		}
+		return pix
+	}
+	for _, tc := range []struct {
+		name    string
+		height  int
+		size    int // Bytes of the file kept, 0 for all
+		bands   int
+		want    []byte
+		wantErr bool
+	}{
+		// Bottom-up files send the band of the last three stored rows first
+		{"BottomUp", h, 0, 2, want(2, 1, 0, 3), false},
+		{"TopDown", -h, 0, 2, want(0, 1, 2, 3), false},
+		{"TopDownFirstBandCut", -h, 66 + 2*w*2, 0, nil, true},
+		{"TopDownSecondBandCut", -h, 66 + 3*w*2 + 10, 1, want(0, 1, 2), true},
+		{"BottomUpSecondBandCut", h, 66 + 3*w*2, 1, want(2, 1, 0), true},
+		{"HeaderOnly", h, 66, 0, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, r := recorderDevice(t)
+			f := bmpFile(w, tc.height, bmpBGR565, rows)
+			if tc.size != 0 {
+				f = f[:tc.size]
+			}
+			err := d.DrawBMP(0, 0, bytes.NewReader(f))
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("DrawBMP() = %v, want error %t", err, tc.wantErr)
+			}
+			if tc.wantErr && !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("DrawBMP() = %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+			bands := 0
+			for _, op := range r.Ops {
+				if op.Kind == OpPixels {
+					bands++
+				}
+			}
+			if bands != tc.bands {
+				t.Errorf("sent %d bands, want %d", bands, tc.bands)
+			}
+			if got := sentPixels(r); !bytes.Equal(got, tc.want) {
+				t.Errorf("sent %d bytes of pixels, want %d", len(got), len(tc.want))
+			}
+		})
+	}
+}
+
+// Only the rows and columns on the display are read into the transfer buffer.
+func TestDrawBMPClipped(t *testing.T) {
+	d, r := recorderDevice(t)
+	rows := bmpRows(4, 3)
+	if err := d.DrawBMP(170, -1, bytes.NewReader(bmpFile(4, -3, bmpBGR565, rows))); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x01, 0x00, 0x01, 0x01, 0x02, 0x00, 0x02, 0x01}
+	if got := sentPixels(r); !bytes.Equal(got, want) {
+		t.Errorf("sent %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+)
+
+// Capture returns the pixels of r, clipped to the display, as an
+// *RGB565Image.
+//
+// With a shadow framebuffer (see EnableShadow) the pixels are copied from
+// memory. Otherwise they are read back from the panel's memory with RAMRD,
+// one row at a time. That needs a bus that can read, either a MISO line with
+// the panel's CS on a GPIO (see Config.UseCS) or the bidirectional SDA line
+// of 3-wire SPI, and usually a lower clock than writing: panels read
+// reliably up to around 6 to 15 MHz.
+func (d *Device) Capture(r image.Rectangle) (image.Image, error) {
+	r = r.Intersect(d.bounds())
+	if r.Empty() {
+		return nil, errors.New("capture rectangle outside display area")
+	}
+	if d.shadow != nil {
+		img := NewRGB565Image(r)
+		fb := d.shadow.fb
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			i := fb.PixOffset(r.Min.X, y)
+			copy(img.Pix[img.PixOffset(r.Min.X, y):], fb.Pix[i:i+r.Dx()*2])
+		}
+		return img, nil
+	}
+	return d.readPixels(r)
+}
+
+// readPixels reads r, which must be inside the display, back from the
+// panel's memory
+func (d *Device) readPixels(r image.Rectangle) (*RGB565Image, error) {
+	img := NewRGB565Image(r)
+	// RAMRD answers with a dummy byte, then 3 bytes per pixel even in 16 bit
+	// mode: each 5 or 6 bit channel in the top bits of its byte, in the
+	// order they were written
+	line := make([]byte, 1+r.Dx()*3)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		d.setWindow(int16(r.Min.X), int16(y), int16(r.Dx()), 1)
+		if err := d.transport.Read(RAMRD, line); err != nil {
+			return nil, err
+		}
+		row := img.Pix[img.PixOffset(r.Min.X, y):]
+		for i := 0; i < r.Dx(); i++ {
+			p := line[1+i*3:]
+			put565(row[i*2:], uint16(p[0]>>3)<<11|uint16(p[1]>>2)<<5|uint16(p[2]>>3))
+		}
+	}
+	return img, nil
+}
+
+// EnableShadow starts or stops keeping a shadow framebuffer: a copy in
+// memory of every pixel drawn, which Capture then returns without reading
+// the panel. It costs two bytes per pixel and a copy of every pixel sent.
+//
+// The shadow starts out black, so it matches the panel from the next full
+// screen redraw. It is cleared when the rotation changes.
+func (d *Device) EnableShadow(enable bool) {
+	if enable == (d.shadow != nil) {
+		return
+	}
+	if !enable {
+		d.transport = d.shadow.Transport
+		d.shadow = nil
+		return
+	}
+	d.shadow = &shadowTransport{Transport: d.transport}
+	d.shadow.reset(d)
+	d.transport = d.shadow
+	// The shadow has to see the next window being programmed
+	d.window.invalidate()
+}
+
+// shadowTransport passes everything on to the panel, keeping a copy of the
+// pixels written to its memory. It follows the address window like the panel
+// does, from CASET, RASET and RAMWR.
+type shadowTransport struct {
+	Transport
+	fb         *RGB565Image
+	offX, offY int    // Panel offsets included in the window addresses
+	xs, xe     int    // Column range, in panel addresses
+	ys, ye     int    // Row range
+	pos        int    // Pixels written since RAMWR
+	cmd        uint8  // Last command, waiting for its parameters
+	params     []byte // Parameters received so far with WriteData
+}
+
+// reset clears the shadow and adapts it to d's current size and offsets
+func (s *shadowTransport) reset(d *Device) {
+	s.fb = NewRGB565Image(d.bounds())
+	s.offX, s.offY = int(d.columnOffset), int(d.rowOffset)
+}
+
+func (s *shadowTransport) configure(cfg transportConfig) {
+	if c, ok := s.Transport.(configurable); ok {
+		c.configure(cfg)
+	}
+}
+
+// WriteCommand implements Transport.
+func (s *shadowTransport) WriteCommand(cmd uint8, params []byte) error {
+	s.cmd, s.params = cmd, s.params[:0]
+	if cmd == RAMWR {
+		s.pos = 0
+	}
+	s.parameters(params)
+	return s.Transport.WriteCommand(cmd, params)
+}
+
+// WriteData implements Transport.
+func (s *shadowTransport) WriteData(data []byte) error {
+	s.parameters(data)
+	return s.Transport.WriteData(data)
+}
+
+// WritePixels implements Transport.
+func (s *shadowTransport) WritePixels(pix []byte, count int) error {
+	for i := 0; i < count; i++ {
+		s.store(pix)
+	}
+	return s.Transport.WritePixels(pix, count)
+}
+
+// parameters collects the address window from CASET and RASET, whether the
+// parameters come with the command or byte by byte after it
+func (s *shadowTransport) parameters(p []byte) {
+	if s.cmd != CASET && s.cmd != RASET || len(p) == 0 {
+		return
+	}
+	s.params = append(s.params, p...)
+	if len(s.params) < 4 {
+		return
+	}
+	start := int(s.params[0])<<8 | int(s.params[1])
+	end := int(s.params[2])<<8 | int(s.params[3])
+	if s.cmd == CASET {
+		s.xs, s.xe = start, end
+	} else {
+		s.ys, s.ye = start, end
+	}
+	s.cmd = 0
+}
+
+// store copies pixels written at the current position in the window,
+// wrapping around at its end like the panel
+func (s *shadowTransport) store(pix []byte) {
+	w, h := s.xe-s.xs+1, s.ye-s.ys+1
+	if w <= 0 || h <= 0 {
+		return
+	}
+	fb := s.fb
+	for len(pix) >= 2 {
+		col, row := s.pos%w, s.pos/w
+		n := w - col
+		if n > len(pix)/2 {
+			n = len(pix) / 2
+		}
+		// Clip the run to the shadow
+		x, y := s.xs-s.offX+col, s.ys-s.offY+row
+		x0, x1 := x, x+n
+		if x0 < 0 {
+			x0 = 0
+		}
+		if x1 > fb.Rect.Max.X {
+			x1 = fb.Rect.Max.X
+		}
+		if y >= 0 && y < fb.Rect.Max.Y && x0 < x1 {
+			copy(fb.Pix[fb.PixOffset(x0, y):], pix[(x0-x)*2:(x1-x)*2])
+		}
+		pix = pix[n*2:]
+		s.pos = (s.pos + n) % (w * h)
+	}
+}
@@ -0,0 +1,77 @@
+// Command gc9307-asset converts PNG, JPEG and GIF images to the precompiled
+// RGB565 asset format drawn by Device.DrawAsset.
+//
+// Usage:
+//
+//	gc9307-asset [-rle] [-alpha] [-dither] [-raw] [-o out.g565] image.png
+//
+// Without -o the output is written next to the input, with a .g565
+// extension. For an animated GIF only the first frame is converted.
+//
+// With -raw only the pixel data is written, without the header and mask,
+// e.g. to embed compressed pixels for gc9307.ParseRLEImage.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gc9307 "github.com/photonicat/periph.io-gc9307"
+)
+
+func main() {
+	out := flag.String("o", "", "output file")
+	rle := flag.Bool("rle", false, "run-length compress the pixels")
+	alpha := flag.Bool("alpha", false, "keep a transparency mask")
+	dither := flag.Bool("dither", false, "dither colors down to RGB565")
+	raw := flag.Bool("raw", false, "write the pixel data only")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] image\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	in := flag.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(in, filepath.Ext(in)) + ".g565"
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	img, format, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", in, err)
+	}
+
+	opts := gc9307.AssetOptions{RLE: *rle, Alpha: *alpha, Dither: *dither}
+	asset, err := gc9307.NewAsset(img, opts)
+	if err != nil {
+		log.Fatalf("%s: %v", in, err)
+	}
+	var buf bytes.Buffer
+	if *raw {
+		buf.Write(asset.Pix)
+	} else if err := asset.Encode(&buf); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	b := img.Bounds()
+	fmt.Printf("%s: %s %dx%d -> %s, %d bytes\n", in, format, b.Dx(), b.Dy(), *out, buf.Len())
+}
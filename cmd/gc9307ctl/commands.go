@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gc9307 "periph.io/gc9307"
+	"periph.io/x/conn/v3/physic"
+)
+
+// command is a gc9307ctl subcommand.
+type command struct {
+	name string
+	args string // Synopsis of the arguments
+	help string
+	run  func(p *panel, fs *flag.FlagSet, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"init", "", "reset and initialize the panel, clearing it", cmdInit},
+		{"fill", "color", "fill the screen with a color", cmdFill},
+		{"show", "[-mode fit|fill|stretch|center] [-filter name] [-bg color] image", "show an image file or .g565 asset", cmdShow},
+		{"text", "[-size n] [-font file] [-fg color] [-bg color] [-align a] [-valign a] text...", "show wrapped text, - reads it from stdin", cmdText},
+		{"backlight", "percent", "set the backlight brightness; without a sysfs device, any value above 0 is fully on", cmdBacklight},
+		{"rotate", "[-save] degrees", "rotate the panel and show which side is up", cmdRotate},
+		{"sleep", "", "turn the display off and put the panel to sleep", cmdSleep},
+		{"wake", "", "wake the panel and turn the display on", cmdWake},
+		{"info", "", "print the settings and read the panel IDs and status", cmdInfo},
+		{"pattern", "[-columns list] [-rows list] name", "show a test pattern, or offsets to find the panel offsets", cmdPattern},
+		{"screenshot", "[-o file.png] [-read-speed f]", "read the screen contents back into a PNG", cmdScreenshot},
+		{"shell", "[-record file] [script...]", "register-level debug shell, or run shell scripts", cmdShell},
+	}
+}
+
+// run runs the command named by args[0] with the rest of args.
+func (p *panel) run(args []string) error {
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "usage: %s %s %s\n", filepath.Base(os.Args[0]), c.name, c.args)
+			fs.PrintDefaults()
+		}
+		return c.run(p, fs, args[1:])
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// parseArgs parses the flags of a command and checks that n arguments
+// follow, or at least one when n is negative.
+func parseArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if n >= 0 && fs.NArg() != n || n < 0 && fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+func cmdInit(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if err := os.Remove(initializedFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	p.configured = false
+	d, err := p.device(false)
+	if err != nil {
+		return err
+	}
+	w, h := d.Size()
+	fmt.Printf("initialized %dx%d\n", w, h)
+	return nil
+}
+
+func cmdFill(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	c, err := parseColor(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	w, h := d.Size()
+	return d.FillRectangle(0, 0, w, h, c)
+}
+
+var scaleModes = map[string]gc9307.ScaleMode{
+	"fit":     gc9307.ScaleFit,
+	"fill":    gc9307.ScaleFill,
+	"stretch": gc9307.ScaleStretch,
+	"center":  gc9307.ScaleCenter,
+}
+
+var filters = map[string]gc9307.Filter{
+	"nearest":  gc9307.NearestNeighbor,
+	"bilinear": gc9307.Bilinear,
+	"box":      gc9307.BoxFilter,
+}
+
+func cmdShow(p *panel, fs *flag.FlagSet, args []string) error {
+	modeName := fs.String("mode", "fit", "scaling: fit, fill, stretch or center")
+	filterName := fs.String("filter", "bilinear", "resampling: nearest, bilinear or box")
+	bgName := fs.String("bg", "black", "color around the image")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	mode, ok := scaleModes[*modeName]
+	if !ok {
+		return fmt.Errorf("unknown scaling mode %q", *modeName)
+	}
+	filter, ok := filters[*filterName]
+	if !ok {
+		return fmt.Errorf("unknown filter %q", *filterName)
+	}
+	bg, err := parseColor(*bgName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	// Assets are drawn as they are; everything else goes through image.Decode,
+	// which also knows QOI and 16-bit BMP from the driver
+	var asset *gc9307.Asset
+	var img image.Image
+	if magic, _ := br.Peek(4); string(magic) == "G565" {
+		asset, err = gc9307.DecodeAsset(br)
+	} else {
+		img, _, err = image.Decode(br)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	w, h := d.Size()
+	if err := d.FillRectangle(0, 0, w, h, bg); err != nil {
+		return err
+	}
+	if asset != nil {
+		return d.DrawAsset((w-int16(asset.Width))/2, (h-int16(asset.Height))/2, asset)
+	}
+	return d.DrawImageFit(image.Rect(0, 0, int(w), int(h)), img, mode, filter)
+}
+
+// The built-in fonts, from the smallest
+var fontSizes = []gc9307.Font{gc9307.Font6x11, gc9307.Font8x16, gc9307.Font6x11Scale2, gc9307.Font6x11Scale3}
+
+var aligns = map[string]gc9307.Align{
+	"left":   gc9307.AlignLeft,
+	"center": gc9307.AlignCenter,
+	"right":  gc9307.AlignRight,
+}
+
+var valigns = map[string]gc9307.VAlign{
+	"top":    gc9307.AlignTop,
+	"middle": gc9307.AlignMiddle,
+	"bottom": gc9307.AlignBottom,
+}
+
+func cmdText(p *panel, fs *flag.FlagSet, args []string) error {
+	size := fs.Int("size", 3, "built-in font, 1 to 4: 6x11, 8x16, 12x22 or 18x33")
+	fontFile := fs.String("font", "", "BDF or PCF font file, instead of a built-in font")
+	fgName := fs.String("fg", "white", "text color")
+	bgName := fs.String("bg", "black", "background color")
+	alignName := fs.String("align", "left", "horizontal alignment: left, center or right")
+	valignName := fs.String("valign", "top", "vertical alignment: top, middle or bottom")
+	margin := fs.Int("margin", 4, "space around the text")
+	if err := parseArgs(fs, args, -1); err != nil {
+		return err
+	}
+	box := gc9307.TextBox{Wrap: true, Ellipsis: true}
+	var err error
+	if box.FG, err = parseColor(*fgName); err != nil {
+		return err
+	}
+	if box.BG, err = parseColor(*bgName); err != nil {
+		return err
+	}
+	var ok bool
+	if box.Align, ok = aligns[*alignName]; !ok {
+		return fmt.Errorf("unknown alignment %q", *alignName)
+	}
+	if box.VAlign, ok = valigns[*valignName]; !ok {
+		return fmt.Errorf("unknown vertical alignment %q", *valignName)
+	}
+	if *fontFile != "" {
+		if box.Font, err = loadFont(*fontFile); err != nil {
+			return err
+		}
+	} else if *size >= 1 && *size <= len(fontSizes) {
+		box.Font = fontSizes[*size-1]
+	} else {
+		return fmt.Errorf("font size %d out of range", *size)
+	}
+
+	text := strings.Join(fs.Args(), " ")
+	if text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = strings.TrimRight(string(data), "\n")
+	}
+
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	w, h := d.Size()
+	if err := d.FillRectangle(0, 0, w, h, box.BG); err != nil {
+		return err
+	}
+	r := image.Rect(0, 0, int(w), int(h)).Inset(*margin)
+	_, err = d.DrawTextBox(r, text, &box)
+	return err
+}
+
+// loadFont reads a PCF font, or a BDF font for any other extension.
+func loadFont(path string) (gc9307.Font, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var font gc9307.Font
+	if strings.EqualFold(filepath.Ext(path), ".pcf") {
+		font, err = gc9307.ParsePCF(f)
+	} else {
+		font, err = gc9307.ParseBDF(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return font, nil
+}
+
+func cmdBacklight(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	pct, err := strconv.Atoi(strings.TrimSuffix(fs.Arg(0), "%"))
+	if err != nil || pct < 0 || pct > 100 {
+		return fmt.Errorf("invalid brightness %q, want 0 to 100", fs.Arg(0))
+	}
+	// A PWM backlight driver gives levels; a plain pin is only on or off
+	if dir := p.cfg.BacklightDevice; dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, "max_brightness"))
+		if err == nil {
+			maxLevel, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				return fmt.Errorf("%s: %v", dir, err)
+			}
+			level := strconv.Itoa((pct*maxLevel + 50) / 100)
+			return os.WriteFile(filepath.Join(dir, "brightness"), []byte(level), 0o644)
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if p.cfg.Backlight == "" {
+		return errors.New("no backlight device or pin configured")
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	d.EnableBacklight(pct > 0)
+	return nil
+}
+
+func cmdRotate(p *panel, fs *flag.FlagSet, args []string) error {
+	save := fs.Bool("save", false, "write the rotation to the config file")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	degrees, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid rotation %q", fs.Arg(0))
+	}
+	rotation, err := parseRotation(degrees)
+	if err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	p.cfg.Rotation = degrees
+	d.SetRotation(rotation)
+	// What was on screen is now drawn the wrong way; show the new orientation
+	if err := d.DrawPattern(gc9307.PatternEdges); err != nil {
+		return err
+	}
+	if *save {
+		return saveConfig(p.cfg, p.configPath)
+	}
+	return nil
+}
+
+func cmdSleep(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	d.EnableBacklight(false)
+	d.Sleep(true)
+	return nil
+}
+
+func cmdWake(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	d.Sleep(false)
+	d.EnableBacklight(true)
+	return nil
+}
+
+func cmdInfo(p *panel, fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	cfg := p.cfg
+	fmt.Printf("spi:        %s at %s\n", cfg.SPI, cfg.Speed)
+	fmt.Printf("pins:       reset=%q dc=%q cs=%q backlight=%q\n", cfg.Reset, cfg.DC, cfg.CS, cfg.Backlight)
+	fmt.Printf("panel:      %dx%d, offsets %d,%d, rotation %d\n", cfg.Width, cfg.Height, cfg.ColumnOffset, cfg.RowOffset, cfg.Rotation)
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+
+	var id, status, power, madctl, colmod, id1, id2, id3 []byte
+	for _, r := range []struct {
+		cmd uint8
+		n   int
+		dst *[]byte
+	}{
+		{gc9307.RDDID, 3, &id},
+		{gc9307.RDDST, 4, &status},
+		{gc9307.RDDPM, 1, &power},
+		{gc9307.RDDMADCTL, 1, &madctl},
+		{gc9307.RDDCOLMOD, 1, &colmod},
+		{gc9307.RDID1, 1, &id1},
+		{gc9307.RDID2, 1, &id2},
+		{gc9307.RDID3, 1, &id3},
+	} {
+		// Replies start with a dummy byte, as for RAMRD
+		buf := make([]byte, 1+r.n)
+		if err := d.ReadRegister(r.cmd, buf); err != nil {
+			return fmt.Errorf("reading %s: %v", registers[r.cmd].name, err)
+		}
+		*r.dst = buf[1:]
+	}
+	fmt.Printf("id:         % X (ID1-3 % X % X % X)\n", id, id1[0], id2[0], id3[0])
+	fmt.Printf("status:     % X\n", status)
+	fmt.Printf("power mode: %02X %s\n", power[0], flags(power[0], []string{
+		7: "booster", 6: "idle", 5: "partial", 4: "sleep-out", 3: "normal", 2: "display-on"}))
+	fmt.Printf("madctl:     %02X %s\n", madctl[0], flags(madctl[0], []string{
+		7: "MY", 6: "MX", 5: "MV", 4: "ML", 3: "BGR", 2: "MH"}))
+	depth := map[byte]string{3: "12 bit", 5: "16 bit", 6: "18 bit"}[colmod[0]&7]
+	fmt.Printf("pixel:      %02X %s\n", colmod[0], depth)
+
+	blank := true
+	for _, b := range [][]byte{id, status, power} {
+		for _, v := range b {
+			blank = blank && (v == 0 || v == 0xFF)
+		}
+	}
+	if blank {
+		fmt.Println("note:       only 00 or FF read back; the panel may not be wired for reading")
+	}
+	return nil
+}
+
+// flags lists the names of the bits set in v, by bit number.
+func flags(v byte, names []string) string {
+	var set []string
+	for bit := len(names) - 1; bit >= 0; bit-- {
+		if names[bit] != "" && v&(1<<bit) != 0 {
+			set = append(set, names[bit])
+		}
+	}
+	return strings.Join(set, " ")
+}
+
+func cmdPattern(p *panel, fs *flag.FlagSet, args []string) error {
+	columns := fs.String("columns", "", "candidate column offsets for the offsets pattern (default the configured one)")
+	rows := fs.String("rows", "", "candidate row offsets for the offsets pattern (default the configured one)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s pattern [-columns list] [-rows list] name\n\nNames: offsets", filepath.Base(os.Args[0]))
+		for pat := gc9307.Pattern(0); ; pat++ {
+			name := pat.String()
+			if _, err := gc9307.ParsePattern(name); err != nil {
+				break
+			}
+			fmt.Fprintf(fs.Output(), ", %s", name)
+		}
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if fs.Arg(0) == "offsets" {
+		cols, err := parseOffsets(*columns, p.cfg.ColumnOffset)
+		if err != nil {
+			return err
+		}
+		rws, err := parseOffsets(*rows, p.cfg.RowOffset)
+		if err != nil {
+			return err
+		}
+		d, err := p.device(true)
+		if err != nil {
+			return err
+		}
+		return d.DrawOffsetMarkers(cols, rws)
+	}
+	pat, err := gc9307.ParsePattern(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	return d.DrawPattern(pat)
+}
+
+// parseOffsets parses a comma separated list of offsets, def for none.
+func parseOffsets(list string, def int) ([]int16, error) {
+	if list == "" {
+		return []int16{int16(def)}, nil
+	}
+	var offsets []int16
+	for _, s := range strings.Split(list, ",") {
+		v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q", s)
+		}
+		offsets = append(offsets, int16(v))
+	}
+	return offsets, nil
+}
+
+func cmdScreenshot(p *panel, fs *flag.FlagSet, args []string) error {
+	out := fs.String("o", "screenshot.png", "output PNG file, - for stdout")
+	var readSpeed physic.Frequency
+	fs.Var(&readSpeed, "read-speed", "SPI clock while reading, if lower than -speed is needed")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	d, err := p.device(true)
+	if err != nil {
+		return err
+	}
+	if readSpeed > 0 {
+		if err := p.port.LimitSpeed(readSpeed); err != nil {
+			return err
+		}
+	}
+	w, h := d.Size()
+	img, err := d.Capture(image.Rect(0, 0, int(w), int(h)))
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		return png.Encode(os.Stdout, img)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var colorNames = map[string]color.RGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 255, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"orange":  {255, 165, 0, 255},
+}
+
+// parseColor parses a color name or a hex RGB value, as #rrggbb or #rgb
+// with the # optional.
+func parseColor(s string) (color.RGBA, error) {
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	switch {
+	case err == nil && len(hex) == 6:
+		return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+	case err == nil && len(hex) == 3:
+		r, g, b := uint8(v>>8&0xF), uint8(v>>4&0xF), uint8(v&0xF)
+		return color.RGBA{r * 0x11, g * 0x11, b * 0x11, 255}, nil
+	}
+	return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	gc9307 "periph.io/gc9307"
+)
+
+// register describes a panel command for the shell.
+type register struct {
+	name string
+	desc string
+	// decode describes the parameters, when there is more to say than the
+	// bytes themselves
+	decode func(p []byte) string
+}
+
+var registers = map[byte]register{
+	gc9307.NOP:       {"NOP", "no operation", nil},
+	gc9307.SWRESET:   {"SWRESET", "software reset", nil},
+	gc9307.RDDID:     {"RDDID", "read display ID", nil},
+	gc9307.RDDST:     {"RDDST", "read display status", nil},
+	gc9307.RDDPM:     {"RDDPM", "read power mode", nil},
+	gc9307.RDDMADCTL: {"RDDMADCTL", "read memory access control", nil},
+	gc9307.RDDCOLMOD: {"RDDCOLMOD", "read pixel format", nil},
+	gc9307.SLPIN:     {"SLPIN", "sleep in", nil},
+	gc9307.SLPOUT:    {"SLPOUT", "sleep out, wait 120 ms before the next command", nil},
+	gc9307.PTLON:     {"PTLON", "partial mode on", nil},
+	gc9307.NORON:     {"NORON", "normal display mode on", nil},
+	gc9307.INVOFF:    {"INVOFF", "display inversion off", nil},
+	gc9307.INVON:     {"INVON", "display inversion on", nil},
+	gc9307.DISPOFF:   {"DISPOFF", "display off", nil},
+	gc9307.DISPON:    {"DISPON", "display on", nil},
+	gc9307.CASET:     {"CASET", "column address set", decodeRange("columns")},
+	gc9307.RASET:     {"RASET", "row address set", decodeRange("rows")},
+	gc9307.RAMWR:     {"RAMWR", "memory write", decodeRAMWR},
+	gc9307.RAMRD:     {"RAMRD", "memory read", nil},
+	gc9307.PTLAR:     {"PTLAR", "partial area", decodeRange("rows")},
+	gc9307.VSCRDEF:   {"VSCRDEF", "vertical scrolling definition", decodeVSCRDEF},
+	0x34:             {"TEOFF", "tearing effect line off", nil},
+	0x35:             {"TEON", "tearing effect line on", decodeTEON},
+	gc9307.MADCTL:    {"MADCTL", "memory access control", decodeMADCTL},
+	gc9307.VSCRSADD:  {"VSCRSADD", "vertical scroll start address", decodeVSCRSADD},
+	0x38:             {"IDMOFF", "idle mode off", nil},
+	0x39:             {"IDMON", "idle mode on, 8 colors", nil},
+	gc9307.COLMOD:    {"COLMOD", "interface pixel format", decodeCOLMOD},
+	gc9307.GSCAN:     {"GSCAN", "read scan line", nil},
+	gc9307.FRMCTR1:   {"FRMCTR1", "frame rate control, normal mode", nil},
+	gc9307.FRMCTR2:   {"FRMCTR2", "frame rate control, idle mode / porch control", nil},
+	gc9307.FRMCTR3:   {"FRMCTR3", "frame rate control, partial mode", nil},
+	gc9307.INVCTR:    {"INVCTR", "display inversion control", nil},
+	gc9307.DISSET5:   {"DISSET5", "display function set", nil},
+	gc9307.PWCTR1:    {"PWCTR1", "power control 1", nil},
+	gc9307.PWCTR2:    {"PWCTR2", "power control 2", nil},
+	gc9307.PWCTR3:    {"PWCTR3", "power control 3", nil},
+	gc9307.PWCTR4:    {"PWCTR4", "power control 4", nil},
+	gc9307.PWCTR5:    {"PWCTR5", "power control 5", nil},
+	gc9307.VMCTR1:    {"VMCTR1", "VCOM control", nil},
+	gc9307.FRCTRL2:   {"FRCTRL2", "frame rate in normal mode", decodeFRCTRL2},
+	gc9307.RDID1:     {"RDID1", "read ID1, manufacturer", nil},
+	gc9307.RDID2:     {"RDID2", "read ID2, version", nil},
+	gc9307.RDID3:     {"RDID3", "read ID3, driver", nil},
+	gc9307.RDID4:     {"RDID4", "read ID4", nil},
+	gc9307.GMCTRP1:   {"GMCTRP1", "positive gamma correction", nil},
+	gc9307.GMCTRN1:   {"GMCTRN1", "negative gamma correction", nil},
+	0xEF:             {"INTEN2", "inter register enable 2, unlocks the vendor registers", nil},
+	gc9307.PWCTR6:    {"PWCTR6", "power control 6", nil},
+	0xFE:             {"INTEN1", "inter register enable 1, unlocks the vendor registers", nil},
+}
+
+// registerByName returns the command code of a register name, in any case.
+func registerByName(name string) (byte, bool) {
+	for code, r := range registers {
+		if strings.EqualFold(r.name, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// describe returns a one-line description of a command write.
+func describe(cmd byte, params []byte) string {
+	r, ok := registers[cmd]
+	if !ok {
+		return "unknown command"
+	}
+	s := r.name + ": " + r.desc
+	if r.decode != nil {
+		if d := r.decode(params); d != "" {
+			s += "; " + d
+		}
+	}
+	return s
+}
+
+// decodeRange describes a start and end address pair.
+func decodeRange(what string) func(p []byte) string {
+	return func(p []byte) string {
+		if len(p) < 4 {
+			return "needs 4 bytes"
+		}
+		return fmt.Sprintf("%s %d to %d", what, be16(p), be16(p[2:]))
+	}
+}
+
+func decodeRAMWR(p []byte) string {
+	return fmt.Sprintf("%d pixels", len(p)/2)
+}
+
+func decodeVSCRDEF(p []byte) string {
+	if len(p) < 6 {
+		return "needs 6 bytes"
+	}
+	return fmt.Sprintf("top fixed %d, scrolling %d, bottom fixed %d", be16(p), be16(p[2:]), be16(p[4:]))
+}
+
+func decodeVSCRSADD(p []byte) string {
+	if len(p) < 2 {
+		return "needs 2 bytes"
+	}
+	return fmt.Sprintf("first line %d", be16(p))
+}
+
+func decodeTEON(p []byte) string {
+	if len(p) < 1 {
+		return ""
+	}
+	if p[0]&1 != 0 {
+		return "V-blank and H-blank"
+	}
+	return "V-blank only"
+}
+
+// MADCTL bits, high to low
+var madctlBits = []struct {
+	mask byte
+	desc string
+}{
+	{gc9307.MADCTL_MY, "MY rows reversed"},
+	{gc9307.MADCTL_MX, "MX columns reversed"},
+	{gc9307.MADCTL_MV, "MV rows and columns exchanged"},
+	{gc9307.MADCTL_ML, "ML refresh bottom to top"},
+	{gc9307.MADCTL_MH, "MH refresh right to left"},
+}
+
+func decodeMADCTL(p []byte) string {
+	if len(p) < 1 {
+		return ""
+	}
+	var set []string
+	for _, b := range madctlBits {
+		if p[0]&b.mask != 0 {
+			set = append(set, b.desc)
+		}
+	}
+	if p[0]&gc9307.MADCTL_BGR != 0 {
+		set = append(set, "BGR")
+	} else {
+		set = append(set, "RGB")
+	}
+	return strings.Join(set, ", ")
+}
+
+func decodeCOLMOD(p []byte) string {
+	if len(p) < 1 {
+		return ""
+	}
+	switch p[0] & 7 {
+	case 3:
+		return "12 bit/pixel"
+	case 5:
+		return "16 bit/pixel, RGB565"
+	case 6:
+		return "18 bit/pixel"
+	}
+	return "reserved pixel format"
+}
+
+// Frame rates of the FRCTRL2 codes, see the FRAMERATE_ constants
+var frameRates = [...]int{0: 119, 111, 105, 99, 94, 90, 86, 82, 78, 75, 72, 69, 67, 64, 62, 60,
+	58, 57, 55, 53, 52, 50, 49, 48, 46, 45, 44, 43, 42, 41, 40, 39}
+
+func decodeFRCTRL2(p []byte) string {
+	if len(p) < 1 {
+		return ""
+	}
+	return fmt.Sprintf("%d Hz", frameRates[p[0]&0x1F])
+}
+
+func be16(p []byte) int {
+	return int(p[0])<<8 | int(p[1])
+}
@@ -0,0 +1,306 @@
+// Command gc9307ctl operates a GC9307 panel from the shell: initialize it,
+// show images, text and test patterns, control the backlight and sleep mode,
+// and read its status and contents back.
+//
+// Usage:
+//
+//	gc9307ctl [flags] command [arguments]
+//
+// The wiring and geometry default to the Photonicat 2 and can be changed
+// with flags or a JSON config file, /etc/gc9307ctl.json unless -config
+// names another one:
+//
+//	{
+//		"spi": "SPI1.0",
+//		"speed": "80MHz",
+//		"reset": "GPIO122",
+//		"dc": "GPIO121",
+//		"cs": "GPIO13",
+//		"backlight": "",
+//		"backlight_device": "/sys/class/backlight/backlight",
+//		"width": 172,
+//		"height": 320,
+//		"column_offset": 34,
+//		"row_offset": 0,
+//		"rotation": 180,
+//		"script_dir": "/etc/gc9307ctl.d"
+//	}
+//
+// Flags given on the command line override the file. Pins are named as
+// gpioreg knows them; an empty DC pin selects 3-wire SPI, other empty pins
+// are left unused. A CS pin is driven by the tool, held low across the
+// command and the reply of reads: with 4-wire SPI, info, screenshot and the
+// shell's read only get an answer when the panel's CS is on that pin. Leave
+// it empty when the SPI controller's own CS drives the panel. The script directory holds scripts for the shell
+// command, which sends raw commands to the panel for bring-up.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	gc9307 "periph.io/gc9307"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/conn/v3/spi"
+	"periph.io/x/conn/v3/spi/spireg"
+	"periph.io/x/host/v3"
+)
+
+const (
+	defaultConfigPath = "/etc/gc9307ctl.json"
+	// Marker file Configure uses to skip the reset of a running panel
+	initializedFile = "/tmp/pcat_display_initialized"
+)
+
+// config is the panel wiring and geometry.
+type config struct {
+	SPI             string `json:"spi"`
+	Speed           string `json:"speed"`
+	Reset           string `json:"reset"`
+	DC              string `json:"dc"`
+	CS              string `json:"cs"`
+	Backlight       string `json:"backlight"`
+	BacklightDevice string `json:"backlight_device"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	ColumnOffset    int    `json:"column_offset"`
+	RowOffset       int    `json:"row_offset"`
+	Rotation        int    `json:"rotation"` // Degrees clockwise
+	ScriptDir       string `json:"script_dir"`
+}
+
+var defaultConfig = config{
+	SPI:             "SPI1.0",
+	Speed:           "80MHz",
+	Reset:           "GPIO122",
+	DC:              "GPIO121",
+	CS:              "GPIO13",
+	BacklightDevice: "/sys/class/backlight/backlight",
+	Width:           172,
+	Height:          320,
+	ColumnOffset:    34,
+	Rotation:        180,
+	ScriptDir:       "/etc/gc9307ctl.d",
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gc9307ctl: ")
+
+	cfg := defaultConfig
+	configPath := flag.String("config", "", "JSON config file (default "+defaultConfigPath+" if present)")
+	flag.StringVar(&cfg.SPI, "spi", cfg.SPI, "SPI port")
+	flag.StringVar(&cfg.Speed, "speed", cfg.Speed, "SPI clock")
+	flag.StringVar(&cfg.Reset, "rst", cfg.Reset, "reset pin")
+	flag.StringVar(&cfg.DC, "dc", cfg.DC, "DC pin, empty for 3-wire SPI")
+	flag.StringVar(&cfg.CS, "cs", cfg.CS, "CS pin, driven by the tool so that reads work; empty for the controller's CS")
+	flag.StringVar(&cfg.Backlight, "bl", cfg.Backlight, "backlight pin")
+	flag.StringVar(&cfg.BacklightDevice, "backlight-device", cfg.BacklightDevice, "sysfs backlight directory, used instead of the pin when present")
+	flag.IntVar(&cfg.Width, "width", cfg.Width, "panel width")
+	flag.IntVar(&cfg.Height, "height", cfg.Height, "panel height")
+	flag.IntVar(&cfg.ColumnOffset, "xoff", cfg.ColumnOffset, "column offset")
+	flag.IntVar(&cfg.RowOffset, "yoff", cfg.RowOffset, "row offset")
+	flag.IntVar(&cfg.Rotation, "rotation", cfg.Rotation, "rotation in degrees clockwise")
+	flag.StringVar(&cfg.ScriptDir, "script-dir", cfg.ScriptDir, "directory of shell scripts")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	path := *configPath
+	if path == "" {
+		path = defaultConfigPath
+	}
+	if err := loadConfig(&cfg, path, *configPath != ""); err != nil {
+		log.Fatal(err)
+	}
+
+	p := &panel{cfg: cfg, configPath: path}
+	defer p.close()
+	if err := p.run(flag.Args()); err != nil {
+		p.close()
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [flags] command [arguments]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", c.name, c.help)
+	}
+	fmt.Fprintf(out, "\nRun %s command -h for the arguments of a command.\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// loadConfig reads the config file at path into cfg, then applies the flags
+// set on the command line again so that they take precedence. A missing
+// file is only an error when required.
+func loadConfig(cfg *config, path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	set := map[string]string{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = f.Value.String() })
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for name, value := range set {
+		flag.Set(name, value)
+	}
+	return nil
+}
+
+// saveConfig writes cfg to path.
+func saveConfig(cfg config, path string) error {
+	data, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// panel is the connection to the panel, opened on first use.
+type panel struct {
+	cfg           config
+	configPath    string
+	port          spi.PortCloser
+	dev           *gc9307.Device
+	configured    bool
+	reset, dc, bl gpio.PinIO // dc is nil for 3-wire SPI
+}
+
+// open connects to the panel without configuring it.
+func (p *panel) open() (*gc9307.Device, error) {
+	if p.dev != nil {
+		return p.dev, nil
+	}
+	cfg := p.cfg
+	var speed physic.Frequency
+	if err := speed.Set(cfg.Speed); err != nil {
+		return nil, fmt.Errorf("speed %q: %v", cfg.Speed, err)
+	}
+	// Registers the pins and buses
+	if _, err := host.Init(); err != nil {
+		return nil, err
+	}
+	reset, err := pin(cfg.Reset)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := pin(cfg.CS)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CS != "" && cfg.CS == cfg.Backlight {
+		return nil, fmt.Errorf("CS and backlight both on %s", cfg.CS)
+	}
+	bl, err := pin(cfg.Backlight)
+	if err != nil {
+		return nil, err
+	}
+	var dc gpio.PinIO
+	if cfg.DC != "" {
+		if dc, err = pin(cfg.DC); err != nil {
+			return nil, err
+		}
+	}
+
+	port, err := spireg.Open(cfg.SPI)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := port.Connect(speed, spi.Mode0, 8)
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	var dcOut gpio.PinOut // nil selects 3-wire SPI
+	if dc != nil {
+		dcOut = dc
+	}
+	d := gc9307.New(conn, reset, dcOut, cs, bl)
+	p.port, p.dev = port, &d
+	p.reset, p.dc, p.bl = reset, dc, bl
+	return p.dev, nil
+}
+
+// device connects to the panel and configures it, once. With keep the
+// screen contents are left alone, unless the panel has to be reset because
+// it was not initialized since boot.
+func (p *panel) device(keep bool) (*gc9307.Device, error) {
+	if p.configured {
+		return p.dev, nil
+	}
+	rotation, err := parseRotation(p.cfg.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	d, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	cfg := p.cfg
+	d.Configure(gc9307.Config{
+		Width:        int16(cfg.Width),
+		Height:       int16(cfg.Height),
+		Rotation:     rotation,
+		RowOffset:    int16(cfg.RowOffset),
+		ColumnOffset: int16(cfg.ColumnOffset),
+		FrameRate:    gc9307.FRAMERATE_60,
+		VSyncLines:   gc9307.MAX_VSYNC_SCANLINES,
+		UseCS:        cfg.CS != "", // Reads need CS held across both phases
+		UseDMA:       true,
+		KeepContents: keep,
+	})
+	p.configured = true
+	return d, nil
+}
+
+func (p *panel) close() {
+	if p.port != nil {
+		p.port.Close()
+		p.port, p.dev, p.configured = nil, nil, false
+	}
+}
+
+// pin looks up a GPIO by name; an empty name gives a pin that does nothing.
+func pin(name string) (gpio.PinIO, error) {
+	if name == "" {
+		return gpio.INVALID, nil
+	}
+	p := gpioreg.ByName(name)
+	if p == nil {
+		return nil, fmt.Errorf("unknown pin %q", name)
+	}
+	return p, nil
+}
+
+func parseRotation(degrees int) (gc9307.Rotation, error) {
+	switch degrees {
+	case 0:
+		return gc9307.NO_ROTATION, nil
+	case 90:
+		return gc9307.ROTATION_90, nil
+	case 180:
+		return gc9307.ROTATION_180, nil
+	case 270:
+		return gc9307.ROTATION_270, nil
+	}
+	return 0, fmt.Errorf("invalid rotation %d, want 0, 90, 180 or 270", degrees)
+}
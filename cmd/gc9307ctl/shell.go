@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+// The shell reads one command per line, from the terminal or a script:
+//
+//	MADCTL 40        write a register, by name or number, with data bytes
+//	0x2A 00 22 00 CD
+//	read RDDID 4     send a command and read 4 bytes
+//	delay 120ms      wait; a plain number is in milliseconds
+//	reset            pulse the reset pin; reset low and reset high set it
+//	dc low           set the DC pin; bl on and bl off for the backlight pin
+//	run init         run a built-in or saved script
+//	fill red         any gc9307ctl command
+//
+// A # at the start of a line or before a space starts a comment. Lines that
+// change the panel can be recorded into a script that replays the session.
+
+const shellHelp = `Commands:
+  <register> [bytes...]   write a register, by name or number, e.g. MADCTL 40 or 0x2A 00 22 00 CD
+  read <register> <n>     send a command and read n bytes of its reply
+  delay <duration>        wait, e.g. 120ms; a plain number is in milliseconds
+  reset [low|high]        pulse the reset pin, or set it
+  dc low|high             set the DC pin
+  bl on|off               set the backlight pin
+  run <script>            run a built-in script, a file, or a script from the script directory
+  scripts                 list the built-in and saved scripts
+  record <file>|off       record the commands that follow into a replayable script
+  history, !!, !n         list or repeat earlier lines
+  help, quit
+Any gc9307ctl command works too, e.g. fill red or info.
+Write bytes that read like a command with 0x, e.g. 0xDC.
+`
+
+// Scripts built into the shell, run with "run name"
+var builtinScripts = map[string]string{
+	"init": `# What Device.Configure sends to the default panel: 172x320, column
+# offset 34, rotated 180 degrees. The clear is left out, run fill black
+# after it. Configure waits 10 ms after the reset pulse, this one 120 ms.
+reset
+SWRESET
+delay 10ms
+SLPOUT
+delay 10ms
+COLMOD 55             # 16 bit/pixel
+delay 10ms
+MADCTL 40             # 180 degrees
+CASET 00 22 00 CD     # columns 34 to 205
+RASET 00 00 01 3F     # rows 0 to 319
+RAMWR
+INVOFF
+delay 10ms
+NORON
+delay 10ms
+DISPON
+delay 10ms
+bl on
+`,
+	"sleep": "DISPOFF\nSLPIN\ndelay 5ms\n",
+	"wake":  "SLPOUT\ndelay 120ms\nDISPON\n",
+	"ids":   "read RDDID 4\nread RDID1 2\nread RDID2 2\nread RDID3 2\n",
+}
+
+// Deepest nesting of scripts running scripts
+const maxScriptDepth = 8
+
+var errQuit = errors.New("quit")
+
+// shell is the state of a gc9307ctl shell session.
+type shell struct {
+	p           *panel
+	history     []string
+	historyFile *os.File
+	record      *os.File // Where executed lines are recorded, if anywhere
+	depth       int      // Nesting of running scripts
+}
+
+func cmdShell(p *panel, fs *flag.FlagSet, args []string) error {
+	record := fs.String("record", "", "record the session into a script")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	s := &shell{p: p}
+	defer s.close()
+	if *record != "" {
+		if err := s.startRecording(*record); err != nil {
+			return err
+		}
+	}
+	// Scripts given as arguments run without a prompt
+	if fs.NArg() > 0 {
+		for _, name := range fs.Args() {
+			if err := s.runScript(name); err != nil && err != errQuit {
+				return err
+			}
+		}
+		return nil
+	}
+
+	interactive := isTerminal(os.Stdin)
+	if interactive {
+		s.loadHistory()
+		fmt.Println(`gc9307ctl shell, "help" lists the commands`)
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for n := 1; ; n++ {
+		if interactive {
+			fmt.Print("gc9307> ")
+		}
+		if !in.Scan() {
+			if interactive {
+				fmt.Println()
+			}
+			return in.Err()
+		}
+		err := s.exec(in.Text(), interactive)
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			if !interactive {
+				return fmt.Errorf("stdin:%d: %v", n, err)
+			}
+			fmt.Println("error:", err)
+		}
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *shell) close() {
+	if s.record != nil {
+		s.record.Close()
+	}
+	if s.historyFile != nil {
+		s.historyFile.Close()
+	}
+}
+
+// loadHistory reads the history of earlier sessions and keeps the file open
+// to add to it. The history is a convenience, so errors are ignored.
+func (s *shell) loadHistory() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(home, ".gc9307ctl_history")
+	if data, err := os.ReadFile(path); err == nil {
+		s.history = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		if len(s.history) > 500 {
+			s.history = s.history[len(s.history)-500:]
+		}
+	}
+	s.historyFile, _ = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+}
+
+func (s *shell) addHistory(line string) {
+	s.history = append(s.history, line)
+	if s.historyFile != nil {
+		fmt.Fprintln(s.historyFile, line)
+	}
+}
+
+func (s *shell) startRecording(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if s.record != nil {
+		s.record.Close()
+	}
+	s.record = f
+	_, err = fmt.Fprintf(f, "# gc9307ctl shell session, %s\n# Replay with: gc9307ctl shell %s\n",
+		time.Now().Format("2006-01-02 15:04"), filepath.Base(path))
+	return err
+}
+
+// exec runs one line. Typed lines go into the history and may repeat
+// earlier ones.
+func (s *shell) exec(line string, typed bool) error {
+	line = strings.TrimSpace(stripComment(line))
+	if line == "" {
+		return nil
+	}
+	if typed {
+		if strings.HasPrefix(line, "!") {
+			prev, err := s.recall(line[1:])
+			if err != nil {
+				return err
+			}
+			line = prev
+			fmt.Println(line)
+		}
+		s.addHistory(line)
+	}
+	args, err := splitLine(line)
+	if err != nil {
+		return err
+	}
+
+	// Commands about the session itself, not recorded
+	switch strings.ToLower(args[0]) {
+	case "help", "?":
+		fmt.Print(shellHelp)
+		return nil
+	case "quit", "exit":
+		return errQuit
+	case "history":
+		for i, h := range s.history {
+			fmt.Printf("%4d  %s\n", i+1, h)
+		}
+		return nil
+	case "scripts":
+		return s.listScripts()
+	case "record":
+		if len(args) != 2 {
+			return errors.New("usage: record <file>|off")
+		}
+		if args[1] == "off" {
+			if s.record == nil {
+				return nil
+			}
+			err := s.record.Close()
+			s.record = nil
+			return err
+		}
+		return s.startRecording(args[1])
+	case "run":
+		if len(args) != 2 {
+			return errors.New("usage: run <script>")
+		}
+		return s.runScript(args[1])
+	case "shell":
+		return errors.New("already in the shell")
+	}
+
+	if err := s.command(args); err != nil {
+		return err
+	}
+	if s.record != nil {
+		if _, err := fmt.Fprintln(s.record, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recall returns the history entry for "!" followed by ref: "!" for the
+// last line, or a number as listed by history.
+func (s *shell) recall(ref string) (string, error) {
+	if len(s.history) == 0 {
+		return "", errors.New("no history")
+	}
+	if ref == "!" {
+		return s.history[len(s.history)-1], nil
+	}
+	n, err := strconv.Atoi(ref)
+	if err != nil || n < 1 || n > len(s.history) {
+		return "", fmt.Errorf("no history entry %q", ref)
+	}
+	return s.history[n-1], nil
+}
+
+// command runs a line that acts on the panel.
+func (s *shell) command(args []string) error {
+	for _, c := range commands {
+		if c.name == args[0] {
+			return s.p.run(args)
+		}
+	}
+	switch strings.ToLower(args[0]) {
+	case "delay":
+		if len(args) != 2 {
+			return errors.New("usage: delay <duration>")
+		}
+		d, err := parseDelay(args[1])
+		if err != nil {
+			return err
+		}
+		time.Sleep(d)
+		return nil
+	case "reset":
+		return s.reset(args[1:])
+	case "dc":
+		if len(args) != 2 {
+			return errors.New("usage: dc low|high")
+		}
+		if _, err := s.p.open(); err != nil {
+			return err
+		}
+		if s.p.dc == nil {
+			return errors.New("no DC pin with 3-wire SPI")
+		}
+		return setPin(s.p.dc, args[1])
+	case "bl":
+		if len(args) != 2 {
+			return errors.New("usage: bl on|off")
+		}
+		if _, err := s.p.open(); err != nil {
+			return err
+		}
+		return setPin(s.p.bl, args[1])
+	case "read":
+		return s.read(args[1:])
+	}
+	return s.write(args)
+}
+
+// reset pulses the reset pin as Configure does, or sets it.
+func (s *shell) reset(args []string) error {
+	if _, err := s.p.open(); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return setPin(s.p.reset, args[0])
+	}
+	if len(args) > 1 {
+		return errors.New("usage: reset [low|high]")
+	}
+	for _, step := range []struct {
+		level gpio.Level
+		wait  time.Duration
+	}{{gpio.High, 10 * time.Millisecond}, {gpio.Low, 50 * time.Millisecond}, {gpio.High, 120 * time.Millisecond}} {
+		if err := s.p.reset.Out(step.level); err != nil {
+			return err
+		}
+		time.Sleep(step.wait)
+	}
+	return nil
+}
+
+func setPin(p gpio.PinIO, level string) error {
+	switch strings.ToLower(level) {
+	case "high", "on", "1":
+		return p.Out(gpio.High)
+	case "low", "off", "0":
+		return p.Out(gpio.Low)
+	}
+	return fmt.Errorf("invalid level %q, want low or high", level)
+}
+
+// write sends a register name or number with its data bytes.
+func (s *shell) write(args []string) error {
+	cmd, err := parseRegister(args[0])
+	if err != nil {
+		return err
+	}
+	params := make([]byte, len(args)-1)
+	for i, a := range args[1:] {
+		if params[i], err = parseByte(a); err != nil {
+			return err
+		}
+	}
+	d, err := s.p.open()
+	if err != nil {
+		return err
+	}
+	if err := d.WriteRegister(cmd, params...); err != nil {
+		return err
+	}
+	fmt.Printf("-> %-20s %s\n", hexBytes(append([]byte{cmd}, params...)), describe(cmd, params))
+	return nil
+}
+
+func (s *shell) read(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: read <register> <n>")
+	}
+	cmd, err := parseRegister(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > 4096 {
+		return fmt.Errorf("invalid length %q", args[1])
+	}
+	d, err := s.p.open()
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, n)
+	if err := d.ReadRegister(cmd, buf); err != nil {
+		return err
+	}
+	name := "unknown command"
+	if r, ok := registers[cmd]; ok {
+		name = r.name
+	}
+	fmt.Printf("<- %02X: %-16s %s\n", cmd, hexBytes(buf), name)
+	return nil
+}
+
+// runScript runs the lines of a script, stopping at the first error.
+func (s *shell) runScript(name string) error {
+	text, label, err := s.script(name)
+	if err != nil {
+		return err
+	}
+	if s.depth >= maxScriptDepth {
+		return errors.New("scripts nested too deeply")
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+	for i, line := range strings.Split(text, "\n") {
+		if err := s.exec(line, false); err != nil {
+			if err == errQuit {
+				return err
+			}
+			return fmt.Errorf("%s:%d: %v", label, i+1, err)
+		}
+	}
+	return nil
+}
+
+// script returns the text of a built-in script, a file, or a file in the
+// script directory, in that order, and a name for error messages.
+func (s *shell) script(name string) (text, label string, err error) {
+	if text, ok := builtinScripts[name]; ok {
+		return text, name, nil
+	}
+	paths := []string{name}
+	if dir := s.p.cfg.ScriptDir; dir != "" && !strings.ContainsRune(name, os.PathSeparator) {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return string(data), path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+	}
+	return "", "", fmt.Errorf("no script %q", name)
+}
+
+func (s *shell) listScripts() error {
+	var names []string
+	for name := range builtinScripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("built-in:", strings.Join(names, " "))
+	dir := s.p.cfg.ScriptDir
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	names = names[:0]
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	fmt.Printf("%s: %s\n", dir, strings.Join(names, " "))
+	return nil
+}
+
+// stripComment removes a comment: a # at the start of the line or followed
+// by a space, so that colors like #ff0000 stay.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitLine splits a line into words at spaces, keeping double quoted
+// strings together.
+func splitLine(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (r == ' ' || r == '\t'):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// parseRegister parses a register name or a command byte.
+func parseRegister(s string) (byte, error) {
+	if code, ok := registerByName(s); ok {
+		return code, nil
+	}
+	b, err := parseByte(s)
+	if err != nil {
+		return 0, fmt.Errorf("unknown command or register %q", s)
+	}
+	return b, nil
+}
+
+// parseByte parses a hexadecimal byte, with or without 0x. A trailing comma
+// is ignored, so that lists can be pasted from C.
+func parseByte(s string) (byte, error) {
+	h := strings.TrimSuffix(s, ",")
+	h = strings.TrimPrefix(strings.TrimPrefix(h, "0x"), "0X")
+	v, err := strconv.ParseUint(h, 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte %q", s)
+	}
+	return byte(v), nil
+}
+
+// parseDelay parses a duration, in milliseconds when it has no unit.
+func parseDelay(s string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(s); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid delay %q", s)
+	}
+	return d, nil
+}
+
+func hexBytes(b []byte) string {
+	return fmt.Sprintf("% X", b)
+}
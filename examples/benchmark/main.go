@@ -47,6 +47,8 @@ type BenchmarkApp struct {
 	maxPanX       int
 	maxPanY       int
 	useDMA        bool
+	usePackets    bool
+	rectSize      int
 	areaPercent   int
 	centerWidth   int
 	centerHeight  int
@@ -54,12 +56,14 @@ type BenchmarkApp struct {
 	startY        int
 }
 
-func NewBenchmarkApp(useDMA bool, areaPercent int) *BenchmarkApp {
+func NewBenchmarkApp(useDMA bool, usePackets bool, areaPercent int, rectSize int) *BenchmarkApp {
 	return &BenchmarkApp{
 		panDirX:     1,
 		panDirY:     1,
 		useDMA:      useDMA,
+		usePackets:  usePackets,
 		areaPercent: areaPercent,
+		rectSize:    rectSize,
 	}
 }
 
@@ -89,6 +93,7 @@ func (app *BenchmarkApp) InitializeDisplay() error {
 		VSyncLines:   gc9307.MAX_VSYNC_SCANLINES,
 		UseCS:        false,
 		UseDMA:       app.useDMA,
+		NoPackets:    !app.usePackets,
 	})
 
 	return nil
@@ -233,6 +238,7 @@ func (app *BenchmarkApp) RunBenchmark(durationSeconds int) {
 	log.Printf("Starting benchmark for %d seconds...", durationSeconds)
 	
 	app.frameCount = 0
+	app.display.ResetWindowStats()
 	app.startTime = time.Now()
 	
 	ticker := time.NewTicker(16 * time.Millisecond) // ~60 FPS target
@@ -269,11 +275,65 @@ func (app *BenchmarkApp) RunBenchmark(durationSeconds int) {
 	fmt.Printf("Total frames: %d\n", app.frameCount)
 	fmt.Printf("Duration: %.2f seconds\n", totalElapsed.Seconds())
 	fmt.Printf("Average FPS: %.2f\n", avgFPS)
+	app.PrintWindowStats()
+}
+
+// PrintWindowStats shows how many window commands the driver's cache saved
+func (app *BenchmarkApp) PrintWindowStats() {
+	stats := app.display.WindowStats()
+	fmt.Printf("CASET sent/skipped: %d/%d\n", stats.ColumnSent, stats.ColumnSkipped)
+	fmt.Printf("RASET sent/skipped: %d/%d\n", stats.RowSent, stats.RowSkipped)
+	fmt.Printf("RAMWR sent: %d\n", stats.MemoryWrites)
+}
+
+// RunRectBenchmark measures small-rectangle throughput, which is dominated
+// by the window setup cost rather than by the pixel data.
+func (app *BenchmarkApp) RunRectBenchmark(durationSeconds int) {
+	log.Printf("Starting %dx%d rectangle benchmark for %d seconds...", app.rectSize, app.rectSize, durationSeconds)
+
+	colors := []color.RGBA{
+		{255, 0, 0, 255},
+		{0, 255, 0, 255},
+		{0, 0, 255, 255},
+		{255, 255, 255, 255},
+	}
+	cols := LCD_WIDTH / app.rectSize
+	rows := LCD_HEIGHT / app.rectSize
+	size := int16(app.rectSize)
+
+	rectCount := 0
+	app.display.ResetWindowStats()
+	app.startTime = time.Now()
+	endTime := app.startTime.Add(time.Duration(durationSeconds) * time.Second)
+
+	for time.Now().Before(endTime) {
+		for i := 0; i < cols*rows; i++ {
+			x := int16(i%cols) * size
+			y := int16(i/cols) * size
+			c := colors[(i+rectCount/(cols*rows))%len(colors)]
+			if err := app.display.FillRectangle(x, y, size, size, c); err != nil {
+				log.Printf("Render error: %v", err)
+			}
+		}
+		rectCount += cols * rows
+	}
+
+	totalElapsed := time.Since(app.startTime)
+	rate := float64(rectCount) / totalElapsed.Seconds()
+
+	fmt.Printf("\nRectangle benchmark completed:\n")
+	fmt.Printf("Packets: %t\n", app.usePackets)
+	fmt.Printf("Total rectangles: %d\n", rectCount)
+	fmt.Printf("Duration: %.2f seconds\n", totalElapsed.Seconds())
+	fmt.Printf("Rectangles/s: %.0f (%.1f us per rectangle)\n", rate, 1e6/rate)
+	app.PrintWindowStats()
 }
 
 func main() {
 	// Command-line flags
 	noDMA := flag.Bool("nodma", false, "Disable DMA transfers (default: false, DMA enabled)")
+	noPackets := flag.Bool("nopackets", false, "Disable batched TxPackets transfers (default: false, packets enabled)")
+	rect := flag.Int("rect", 0, "Benchmark NxN rectangle fills instead of panning (0 disables)")
 	duration := flag.Int("duration", 30, "Benchmark duration in seconds")
 	area := flag.Int("area", DEFAULT_AREA, fmt.Sprintf("Display area percentage (%d-%d%%, default: %d%%)", MIN_AREA, MAX_AREA, DEFAULT_AREA))
 	flag.Parse()
@@ -289,14 +349,21 @@ func main() {
 	}
 
 	useDMA := !*noDMA
-	log.Printf("Starting GC9307 benchmark (DMA: %t, Area: %d%%, Duration: %ds)", useDMA, *area, *duration)
+	usePackets := !*noPackets
+	log.Printf("Starting GC9307 benchmark (DMA: %t, Packets: %t, Area: %d%%, Duration: %ds)", useDMA, usePackets, *area, *duration)
 	
-	app := NewBenchmarkApp(useDMA, *area)
+	app := NewBenchmarkApp(useDMA, usePackets, *area, *rect)
 	
 	log.Println("Initializing display...")
 	if err := app.InitializeDisplay(); err != nil {
 		log.Fatal("Failed to initialize display:", err)
 	}
+
+	if *rect > 0 {
+		app.RunRectBenchmark(*duration)
+		log.Println("Benchmark finished.")
+		return
+	}
 	
 	log.Println("Loading example.png...")
 	if err := app.LoadImage("example.png"); err != nil {
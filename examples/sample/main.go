@@ -2,7 +2,6 @@ package main
 
 import (
 	"image"
-	"image/color"
 	"image/png"
 	"log"
 	"os"
@@ -150,20 +149,12 @@ func displayPNG(display gc9307.Device, x int, y int, filePath string) {
 		log.Fatal(err)
 	}
 
-	// convert image to slice of colors
-	bounds := img.Bounds()
-	width, height := bounds.Max.X, bounds.Max.Y
-	buffer := make([]color.RGBA, height*width)
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			// get pixel color and convert channels from int32 to int8
-			r, g, b, a := img.At(x, y).RGBA()
-			buffer[y*width+x] = color.RGBA{R: uint8(r / 0x100), G: uint8(g / 0x100), B: uint8(b / 0x100), A: uint8(a / 0x100)}
-		}
-	}
+	// scale the image to the rest of the screen, keeping its aspect ratio
+	w, h := display.Size()
+	area := image.Rect(x, y, int(w), int(h))
 
-	// send image buffer to display
-	err = display.FillRectangleWithBuffer(int16(x), int16(y), int16(width), int16(height), buffer)
+	// send image to display
+	err = display.DrawImageFit(area, img, gc9307.ScaleFit, gc9307.BoxFilter)
 	if err != nil {
 		log.Printf("Display error: %v", err)
 		return
@@ -0,0 +1,227 @@
+package gc9307
+
+import (
+	"image/color"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Glyph is the bitmap of one character. Bits holds Height rows of Stride
+// bytes, one bit per pixel with the most significant bit leftmost.
+type Glyph struct {
+	Width, Height int
+	// OffsetX and OffsetY place the top left corner of the bitmap relative to
+	// the pen position on the baseline; OffsetY is negative above it.
+	OffsetX, OffsetY int
+	Advance          int // Distance from this pen position to the next
+	Stride           int
+	Bits             []byte
+}
+
+// At reports whether the pixel at (x, y) of the bitmap is set.
+func (g *Glyph) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return false
+	}
+	return g.Bits[y*g.Stride+x/8]&(0x80>>(x%8)) != 0
+}
+
+// Font provides the glyphs used by DrawText.
+type Font interface {
+	// Glyph returns the glyph for r, or false if the font does not have it.
+	Glyph(r rune) (*Glyph, bool)
+	// Metrics returns the distances in pixels from the baseline to the top
+	// and to the bottom of a line.
+	Metrics() (ascent, descent int)
+}
+
+// FixedFont is one of the built-in fixed width fonts. They cover printable
+// ASCII and Latin-1 (U+0020 to U+007E and U+00A0 to U+00FF).
+type FixedFont struct {
+	glyphs  [0x100 - 0x20]*Glyph
+	ascent  int
+	descent int
+}
+
+// Built-in fonts, named after their cell sizes. There are two designs:
+// Font6x11, and the taller Font8x16 with a 7x16 glyph in each cell. Font6x11Scale2 and Font6x11Scale3 are the bitmaps of
+// Font6x11 with every pixel doubled or tripled, in 12x22 and 18x33 cells.
+// They look blocky; load a BDF or PCF font for smooth large text.
+var (
+	Font6x11       = newFixedFont(fixedGlyphs, 5, 11, 9, 1)
+	Font6x11Scale2 = newFixedFont(fixedGlyphs, 5, 11, 9, 2)
+	Font6x11Scale3 = newFixedFont(fixedGlyphs, 5, 11, 9, 3)
+	Font8x16       = newFixedFont(fixedGlyphs8x16, 7, 16, 13, 1)
+)
+
+// newFixedFont builds a font from the artwork of cellWidth x cellHeight
+// glyphs whose first baseline rows are above the baseline, adding a blank
+// column between characters. Every pixel is scaled to a scale x scale square.
+func newFixedFont(glyphs map[rune]string, cellWidth, cellHeight, baseline, scale int) *FixedFont {
+	f := &FixedFont{ascent: baseline * scale, descent: (cellHeight - baseline) * scale}
+	for r, art := range glyphs {
+		w, h := cellWidth*scale, cellHeight*scale
+		g := &Glyph{
+			Width:   w,
+			Height:  h,
+			OffsetY: -f.ascent,
+			Advance: (cellWidth + 1) * scale,
+			Stride:  (w + 7) / 8,
+		}
+		g.Bits = make([]byte, g.Stride*h)
+		for y, row := range strings.Fields(art) {
+			for x := 0; x < len(row); x++ {
+				if row[x] != '#' {
+					continue
+				}
+				for py := y * scale; py < (y+1)*scale; py++ {
+					for px := x * scale; px < (x+1)*scale; px++ {
+						g.Bits[py*g.Stride+px/8] |= 0x80 >> (px % 8)
+					}
+				}
+			}
+		}
+		f.glyphs[r-0x20] = g
+	}
+	return f
+}
+
+// Glyph implements Font.
+func (f *FixedFont) Glyph(r rune) (*Glyph, bool) {
+	if r < 0x20 || r > 0xFF || f.glyphs[r-0x20] == nil {
+		return nil, false
+	}
+	return f.glyphs[r-0x20], true
+}
+
+// Metrics implements Font.
+func (f *FixedFont) Metrics() (ascent, descent int) {
+	return f.ascent, f.descent
+}
+
+// FontChain is a Font that takes each glyph from the first of its fonts that
+// has it, e.g. a Latin font followed by a CJK font for the characters it
+// lacks. Line metrics are the largest of all fonts, so the baselines line up.
+type FontChain []Font
+
+// Glyph implements Font.
+func (c FontChain) Glyph(r rune) (*Glyph, bool) {
+	for _, f := range c {
+		if g, ok := f.Glyph(r); ok {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// Metrics implements Font.
+func (c FontChain) Metrics() (ascent, descent int) {
+	for _, f := range c {
+		a, d := f.Metrics()
+		if a > ascent {
+			ascent = a
+		}
+		if d > descent {
+			descent = d
+		}
+	}
+	return ascent, descent
+}
+
+// glyphCache decodes glyphs on first use and keeps them, so that fonts with
+// thousands of characters only pay for the ones actually drawn
+type glyphCache struct {
+	mu     sync.Mutex
+	glyphs map[rune]*Glyph // nil for characters known to be missing
+	decode func(r rune) *Glyph
+}
+
+func (c *glyphCache) get(r rune) (*Glyph, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g, ok := c.glyphs[r]
+	if !ok {
+		if c.glyphs == nil {
+			c.glyphs = map[rune]*Glyph{}
+		}
+		g = c.decode(r)
+		c.glyphs[r] = g
+	}
+	return g, g != nil
+}
+
+// isControl reports whether r is a C0 or C1 control character, which
+// DrawText skips
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7F && r < 0xA0)
+}
+
+// lookupGlyph returns the glyph for r, falling back to the replacement
+// character and then to '?' for characters the font does not have
+func lookupGlyph(font Font, r rune) (*Glyph, bool) {
+	for _, c := range [...]rune{r, utf8.RuneError, '?'} {
+		if g, ok := font.Glyph(c); ok {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// MeasureText returns the size of the line DrawText draws for s: the sum of
+// the glyph advances and the font's line height.
+func MeasureText(s string, font Font) (width, height int) {
+	for _, r := range s {
+		if isControl(r) {
+			continue
+		}
+		if g, ok := lookupGlyph(font, r); ok {
+			width += g.Advance
+		}
+	}
+	ascent, descent := font.Metrics()
+	return width, ascent + descent
+}
+
+// renderText draws the glyphs of s into a bitmap of the size MeasureText
+// reports. Glyph pixels falling outside of it are dropped.
+func renderText(s string, font Font) *Bitmap {
+	w, h := MeasureText(s, font)
+	l := NewBitmap(w, h)
+	ascent, _ := font.Metrics()
+	pen := 0
+	for _, r := range s {
+		if isControl(r) {
+			continue
+		}
+		g, ok := lookupGlyph(font, r)
+		if !ok {
+			continue
+		}
+		for gy := 0; gy < g.Height; gy++ {
+			y := ascent + g.OffsetY + gy
+			if y < 0 || y >= h {
+				continue
+			}
+			for gx := 0; gx < g.Width; gx++ {
+				x := pen + g.OffsetX + gx
+				if g.At(gx, gy) {
+					l.Set(x, y, true)
+				}
+			}
+		}
+		pen += g.Advance
+	}
+	return l
+}
+
+// DrawText draws a single line of UTF-8 text with its top left corner at
+// (x, y), clipped to the display. Control characters are ignored, and
+// characters missing from the font are drawn as U+FFFD or '?'.
+//
+// With an opaque bg the whole line is sent through a single address window,
+// background included. When bg is fully transparent (A == 0) only the set
+// pixels are drawn, as horizontal spans, leaving the background untouched.
+func (d *Device) DrawText(x, y int16, s string, font Font, fg, bg color.RGBA) error {
+	return d.drawBitmap(int(x), int(y), renderText(s, font), fg, bg, d.bounds())
+}
@@ -0,0 +1,53 @@
+package gc9307
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFixedFonts(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		font                  *FixedFont
+		glyphs                map[rune]string
+		width, height, ascent int
+	}{
+		{"Font6x11", Font6x11, fixedGlyphs, 5, 11, 9},
+		{"Font6x11Scale3", Font6x11Scale3, fixedGlyphs, 5, 11, 9},
+		{"Font8x16", Font8x16, fixedGlyphs8x16, 7, 16, 13},
+	} {
+		// The artwork has a cell for every printable Latin-1 character
+		for r := rune(0x20); r <= 0xFF; r++ {
+			art, ok := tc.glyphs[r]
+			if isControl(r) {
+				if ok {
+					t.Errorf("%s: glyph for control character %#x", tc.name, r)
+				}
+				continue
+			}
+			rows := strings.Fields(art)
+			if len(rows) != tc.height {
+				t.Errorf("%s: %q has %d rows, want %d", tc.name, r, len(rows), tc.height)
+			}
+			for _, row := range rows {
+				if len(row) != tc.width || strings.Trim(row, ".#") != "" {
+					t.Errorf("%s: %q has row %q, want %d of '.' and '#'", tc.name, r, row, tc.width)
+					break
+				}
+			}
+		}
+
+		scale := tc.font.ascent / tc.ascent
+		if a, d := tc.font.Metrics(); a+d != tc.height*scale {
+			t.Errorf("%s: line height %d, want %d", tc.name, a+d, tc.height*scale)
+		}
+		if w, _ := MeasureText("Ab", tc.font); w != 2*(tc.width+1)*scale {
+			t.Errorf("%s: MeasureText() = %d, want %d", tc.name, w, 2*(tc.width+1)*scale)
+		}
+		// 'l' reaches the cap height, above the baseline
+		g, _ := tc.font.Glyph('l')
+		if g.At(0, 0) || !g.At(2*scale, tc.ascent*scale-1) || g.At(2*scale, tc.ascent*scale) {
+			t.Errorf("%s: 'l' is not drawn on the baseline", tc.name)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package gc9307
+
+// fixedGlyphs is the artwork of Font6x11 and its scaled versions: a 5x11
+// cell for every printable Latin-1 character, '#' for set pixels. Rows 0-1
+// hold the accents of capitals, rows 2-8 the capitals and rows 9-10 the
+// descenders, with the baseline under row 8.
+var fixedGlyphs = map[rune]string{
+	0x20: "..... ..... ..... ..... ..... ..... ..... ..... ..... ..... .....", // space
+	0x21: "..... ..... ..#.. ..#.. ..#.. ..#.. ..#.. ..... ..#.. ..... .....", // !
+	0x22: "..... ..... .#.#. .#.#. .#.#. ..... ..... ..... ..... ..... .....", // "
+	0x23: "..... ..... .#.#. .#.#. ##### .#.#. ##### .#.#. .#.#. ..... .....", // #
+	0x24: "..... ..... ..#.. .#### #.#.. .###. ..#.# ####. ..#.. ..... .....", // $
+	0x25: "..... ..... ##... ##..# ...#. ..#.. .#... #..## ...## ..... .....", // %
+	0x26: "..... ..... .##.. #..#. #.#.. .#... #.#.# #..#. .##.# ..... .....", // &
+	0x27: "..... ..... ..#.. ..#.. .#... ..... ..... ..... ..... ..... .....", // '
+	0x28: "..... ..... ...#. ..#.. .#... .#... .#... ..#.. ...#. ..... .....", // (
+	0x29: "..... ..... .#... ..#.. ...#. ...#. ...#. ..#.. .#... ..... .....", // )
+	0x2A: "..... ..... ..... ..#.. #.#.# .###. #.#.# ..#.. ..... ..... .....", // *
+	0x2B: "..... ..... ..... ..#.. ..#.. ##### ..#.. ..#.. ..... ..... .....", // +
+	0x2C: "..... ..... ..... ..... ..... ..... ..... .##.. ..#.. .#... .....", // ,
+	0x2D: "..... ..... ..... ..... ..... ##### ..... ..... ..... ..... .....", // -
+	0x2E: "..... ..... ..... ..... ..... ..... ..... .##.. .##.. ..... .....", // .
+	0x2F: "..... ..... ..... ....# ...#. ..#.. .#... #.... ..... ..... .....", // /
+	0x30: "..... ..... .###. #...# #..## #.#.# ##..# #...# .###. ..... .....", // 0
+	0x31: "..... ..... ..#.. .##.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // 1
+	0x32: "..... ..... .###. #...# ....# ...#. ..#.. .#... ##### ..... .....", // 2
+	0x33: "..... ..... ##### ...#. ..#.. ...#. ....# #...# .###. ..... .....", // 3
+	0x34: "..... ..... ...#. ..##. .#.#. #..#. ##### ...#. ...#. ..... .....", // 4
+	0x35: "..... ..... ##### #.... ####. ....# ....# #...# .###. ..... .....", // 5
+	0x36: "..... ..... ..##. .#... #.... ####. #...# #...# .###. ..... .....", // 6
+	0x37: "..... ..... ##### ....# ...#. ..#.. .#... .#... .#... ..... .....", // 7
+	0x38: "..... ..... .###. #...# #...# .###. #...# #...# .###. ..... .....", // 8
+	0x39: "..... ..... .###. #...# #...# .#### ....# ...#. .##.. ..... .....", // 9
+	0x3A: "..... ..... ..... .##.. .##.. ..... .##.. .##.. ..... ..... .....", // :
+	0x3B: "..... ..... ..... .##.. .##.. ..... .##.. ..#.. .#... ..... .....", // ;
+	0x3C: "..... ..... ...#. ..#.. .#... #.... .#... ..#.. ...#. ..... .....", // <
+	0x3D: "..... ..... ..... ..... ##### ..... ##### ..... ..... ..... .....", // =
+	0x3E: "..... ..... .#... ..#.. ...#. ....# ...#. ..#.. .#... ..... .....", // >
+	0x3F: "..... ..... .###. #...# ....# ...#. ..#.. ..... ..#.. ..... .....", // ?
+	0x40: "..... ..... .###. #...# ....# .##.# #.#.# #.#.# .###. ..... .....", // @
+	0x41: "..... ..... .###. #...# #...# #...# ##### #...# #...# ..... .....", // A
+	0x42: "..... ..... ####. #...# #...# ####. #...# #...# ####. ..... .....", // B
+	0x43: "..... ..... .###. #...# #.... #.... #.... #...# .###. ..... .....", // C
+	0x44: "..... ..... ###.. #..#. #...# #...# #...# #..#. ###.. ..... .....", // D
+	0x45: "..... ..... ##### #.... #.... ####. #.... #.... ##### ..... .....", // E
+	0x46: "..... ..... ##### #.... #.... ####. #.... #.... #.... ..... .....", // F
+	0x47: "..... ..... .###. #...# #.... #.### #...# #...# .#### ..... .....", // G
+	0x48: "..... ..... #...# #...# #...# ##### #...# #...# #...# ..... .....", // H
+	0x49: "..... ..... .###. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // I
+	0x4A: "..... ..... ..### ...#. ...#. ...#. ...#. #..#. .##.. ..... .....", // J
+	0x4B: "..... ..... #...# #..#. #.#.. ##... #.#.. #..#. #...# ..... .....", // K
+	0x4C: "..... ..... #.... #.... #.... #.... #.... #.... ##### ..... .....", // L
+	0x4D: "..... ..... #...# ##.## #.#.# #.#.# #...# #...# #...# ..... .....", // M
+	0x4E: "..... ..... #...# #...# ##..# #.#.# #..## #...# #...# ..... .....", // N
+	0x4F: "..... ..... .###. #...# #...# #...# #...# #...# .###. ..... .....", // O
+	0x50: "..... ..... ####. #...# #...# ####. #.... #.... #.... ..... .....", // P
+	0x51: "..... ..... .###. #...# #...# #...# #.#.# #..#. .##.# ..... .....", // Q
+	0x52: "..... ..... ####. #...# #...# ####. #.#.. #..#. #...# ..... .....", // R
+	0x53: "..... ..... .#### #.... #.... .###. ....# ....# ####. ..... .....", // S
+	0x54: "..... ..... ##### ..#.. ..#.. ..#.. ..#.. ..#.. ..#.. ..... .....", // T
+	0x55: "..... ..... #...# #...# #...# #...# #...# #...# .###. ..... .....", // U
+	0x56: "..... ..... #...# #...# #...# #...# #...# .#.#. ..#.. ..... .....", // V
+	0x57: "..... ..... #...# #...# #...# #.#.# #.#.# #.#.# .#.#. ..... .....", // W
+	0x58: "..... ..... #...# #...# .#.#. ..#.. .#.#. #...# #...# ..... .....", // X
+	0x59: "..... ..... #...# #...# .#.#. ..#.. ..#.. ..#.. ..#.. ..... .....", // Y
+	0x5A: "..... ..... ##### ....# ...#. ..#.. .#... #.... ##### ..... .....", // Z
+	0x5B: "..... ..... .###. .#... .#... .#... .#... .#... .###. ..... .....", // [
+	0x5C: "..... ..... ..... #.... .#... ..#.. ...#. ....# ..... ..... .....", // \
+	0x5D: "..... ..... .###. ...#. ...#. ...#. ...#. ...#. .###. ..... .....", // ]
+	0x5E: "..... ..... ..#.. .#.#. #...# ..... ..... ..... ..... ..... .....", // ^
+	0x5F: "..... ..... ..... ..... ..... ..... ..... ..... ..... ##### .....", // _
+	0x60: "..... ..... .#... ..#.. ...#. ..... ..... ..... ..... ..... .....", // `
+	0x61: "..... ..... ..... ..... .###. ....# .#### #...# .#### ..... .....", // a
+	0x62: "..... ..... #.... #.... #.##. ##..# #...# #...# ####. ..... .....", // b
+	0x63: "..... ..... ..... ..... .###. #.... #.... #...# .###. ..... .....", // c
+	0x64: "..... ..... ....# ....# .##.# #..## #...# #...# .#### ..... .....", // d
+	0x65: "..... ..... ..... ..... .###. #...# ##### #.... .###. ..... .....", // e
+	0x66: "..... ..... ..##. .#..# .#... ###.. .#... .#... .#... ..... .....", // f
+	0x67: "..... ..... ..... ..... .#### #...# #...# #...# .#### ....# .###.", // g
+	0x68: "..... ..... #.... #.... #.##. ##..# #...# #...# #...# ..... .....", // h
+	0x69: "..... ..... ..#.. ..... .##.. ..#.. ..#.. ..#.. .###. ..... .....", // i
+	0x6A: "..... ..... ...#. ..... ..##. ...#. ...#. ...#. ...#. #..#. .##..", // j
+	0x6B: "..... ..... #.... #.... #..#. #.#.. ##... #.#.. #..#. ..... .....", // k
+	0x6C: "..... ..... .##.. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // l
+	0x6D: "..... ..... ..... ..... ##.#. #.#.# #.#.# #...# #...# ..... .....", // m
+	0x6E: "..... ..... ..... ..... #.##. ##..# #...# #...# #...# ..... .....", // n
+	0x6F: "..... ..... ..... ..... .###. #...# #...# #...# .###. ..... .....", // o
+	0x70: "..... ..... ..... ..... ####. #...# #...# #...# ####. #.... #....", // p
+	0x71: "..... ..... ..... ..... .#### #...# #...# #...# .#### ....# ....#", // q
+	0x72: "..... ..... ..... ..... #.##. ##..# #.... #.... #.... ..... .....", // r
+	0x73: "..... ..... ..... ..... .###. #.... .###. ....# ####. ..... .....", // s
+	0x74: "..... ..... .#... .#... ###.. .#... .#... .#..# ..##. ..... .....", // t
+	0x75: "..... ..... ..... ..... #...# #...# #...# #..## .##.# ..... .....", // u
+	0x76: "..... ..... ..... ..... #...# #...# #...# .#.#. ..#.. ..... .....", // v
+	0x77: "..... ..... ..... ..... #...# #...# #.#.# #.#.# .#.#. ..... .....", // w
+	0x78: "..... ..... ..... ..... #...# .#.#. ..#.. .#.#. #...# ..... .....", // x
+	0x79: "..... ..... ..... ..... #...# #...# #...# #...# .#### ....# .###.", // y
+	0x7A: "..... ..... ..... ..... ##### ...#. ..#.. .#... ##### ..... .....", // z
+	0x7B: "..... ..... ...#. ..#.. ..#.. .#... ..#.. ..#.. ...#. ..... .....", // {
+	0x7C: "..... ..... ..#.. ..#.. ..#.. ..#.. ..#.. ..#.. ..#.. ..... .....", // |
+	0x7D: "..... ..... .#... ..#.. ..#.. ...#. ..#.. ..#.. .#... ..... .....", // }
+	0x7E: "..... ..... ..... ..... .#... #.#.# ...#. ..... ..... ..... .....", // ~
+	0xA0: "..... ..... ..... ..... ..... ..... ..... ..... ..... ..... .....", // no-break space
+	0xA1: "..... ..... ..#.. ..... ..#.. ..#.. ..#.. ..#.. ..#.. ..... .....", // ¡
+	0xA2: "..... ..... ..#.. .###. #.#.. #.#.. #.#.# .###. ..#.. ..... .....", // ¢
+	0xA3: "..... ..... ..##. .#..# .#... ###.. .#... .#..# #.##. ..... .....", // £
+	0xA4: "..... ..... ..... #...# .###. .#.#. .###. #...# ..... ..... .....", // ¤
+	0xA5: "..... ..... #...# .#.#. ##### ..#.. ##### ..#.. ..#.. ..... .....", // ¥
+	0xA6: "..... ..... ..#.. ..#.. ..#.. ..... ..#.. ..#.. ..#.. ..... .....", // ¦
+	0xA7: "..... ..... .###. #.... .##.. .#.#. ..##. ....# .###. ..... .....", // §
+	0xA8: "..... ..... .#.#. ..... ..... ..... ..... ..... ..... ..... .....", // ¨
+	0xA9: "..... ..... .###. #...# ##.## ##..# ##.## #...# .###. ..... .....", // ©
+	0xAA: "..... ..... .##.. ...#. .###. #..#. .###. ..... ####. ..... .....", // ª
+	0xAB: "..... ..... ..... ..#.# .#.#. #.#.. .#.#. ..#.# ..... ..... .....", // «
+	0xAC: "..... ..... ..... ..... ##### ....# ....# ..... ..... ..... .....", // ¬
+	0xAD: "..... ..... ..... ..... ..... .###. ..... ..... ..... ..... .....", // soft hyphen
+	0xAE: "..... ..... .###. ##..# #.#.# ##..# #.#.# #...# .###. ..... .....", // ®
+	0xAF: "..... ..... ##### ..... ..... ..... ..... ..... ..... ..... .....", // ¯
+	0xB0: "..... ..... .##.. #..#. #..#. .##.. ..... ..... ..... ..... .....", // °
+	0xB1: "..... ..... ..#.. ..#.. ##### ..#.. ..#.. ..... ##### ..... .....", // ±
+	0xB2: "..... ..... .##.. #..#. ..#.. .#... ####. ..... ..... ..... .....", // ²
+	0xB3: "..... ..... ###.. ...#. .##.. ...#. ###.. ..... ..... ..... .....", // ³
+	0xB4: "..... ..... ...#. ..#.. ..... ..... ..... ..... ..... ..... .....", // ´
+	0xB5: "..... ..... ..... ..... #...# #...# #...# #..## ###.# #.... #....", // µ
+	0xB6: "..... ..... .#### ###.# ###.# .##.# ..#.# ..#.# ..#.# ..... .....", // ¶
+	0xB7: "..... ..... ..... ..... ..... .##.. .##.. ..... ..... ..... .....", // ·
+	0xB8: "..... ..... ..... ..... ..... ..... ..... ..... ..... ..#.. .##..", // ¸
+	0xB9: "..... ..... .#... ##... .#... .#... ###.. ..... ..... ..... .....", // ¹
+	0xBA: "..... ..... .##.. #..#. #..#. .##.. ..... ####. ..... ..... .....", // º
+	0xBB: "..... ..... ..... #.#.. .#.#. ..#.# .#.#. #.#.. ..... ..... .....", // »
+	0xBC: "..... ..... #...# #..#. #.#.. .#.#. #.##. ..### ...#. ..... .....", // ¼
+	0xBD: "..... ..... #...# #..#. #.#.. .#.## #...# ...#. ..### ..... .....", // ½
+	0xBE: "..... ..... ##... .#..# ##.#. ..#.. .#.#. #.### ...#. ..... .....", // ¾
+	0xBF: "..... ..... ..#.. ..... ..#.. .#... #.... #...# .###. ..... .....", // ¿
+	0xC0: ".#... ..#.. .###. #...# #...# #...# ##### #...# #...# ..... .....", // À
+	0xC1: "...#. ..#.. .###. #...# #...# #...# ##### #...# #...# ..... .....", // Á
+	0xC2: "..#.. .#.#. .###. #...# #...# #...# ##### #...# #...# ..... .....", // Â
+	0xC3: ".##.# #.##. .###. #...# #...# #...# ##### #...# #...# ..... .....", // Ã
+	0xC4: ".#.#. ..... .###. #...# #...# #...# ##### #...# #...# ..... .....", // Ä
+	0xC5: "..#.. .#.#. .###. #...# #...# #...# ##### #...# #...# ..... .....", // Å
+	0xC6: "..... ..... .#### #.#.. #.#.. ##### #.#.. #.#.. #.### ..... .....", // Æ
+	0xC7: "..... ..... .###. #...# #.... #.... #.... #...# .###. ..#.. .##..", // Ç
+	0xC8: ".#... ..#.. ##### #.... #.... ####. #.... #.... ##### ..... .....", // È
+	0xC9: "...#. ..#.. ##### #.... #.... ####. #.... #.... ##### ..... .....", // É
+	0xCA: "..#.. .#.#. ##### #.... #.... ####. #.... #.... ##### ..... .....", // Ê
+	0xCB: ".#.#. ..... ##### #.... #.... ####. #.... #.... ##### ..... .....", // Ë
+	0xCC: ".#... ..#.. .###. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // Ì
+	0xCD: "...#. ..#.. .###. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // Í
+	0xCE: "..#.. .#.#. .###. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // Î
+	0xCF: ".#.#. ..... .###. ..#.. ..#.. ..#.. ..#.. ..#.. .###. ..... .....", // Ï
+	0xD0: "..... ..... ###.. #..#. #...# ###.# #...# #..#. ###.. ..... .....", // Ð
+	0xD1: ".##.# #.##. #...# #...# ##..# #.#.# #..## #...# #...# ..... .....", // Ñ
+	0xD2: ".#... ..#.. .###. #...# #...# #...# #...# #...# .###. ..... .....", // Ò
+	0xD3: "...#. ..#.. .###. #...# #...# #...# #...# #...# .###. ..... .....", // Ó
+	0xD4: "..#.. .#.#. .###. #...# #...# #...# #...# #...# .###. ..... .....", // Ô
+	0xD5: ".##.# #.##. .###. #...# #...# #...# #...# #...# .###. ..... .....", // Õ
+	0xD6: ".#.#. ..... .###. #...# #...# #...# #...# #...# .###. ..... .....", // Ö
+	0xD7: "..... ..... ..... #...# .#.#. ..#.. .#.#. #...# ..... ..... .....", // ×
+	0xD8: "..... ..... .###. #..## #.#.# #.#.# #.#.# ##..# .###. ..... .....", // Ø
+	0xD9: ".#... ..#.. #...# #...# #...# #...# #...# #...# .###. ..... .....", // Ù
+	0xDA: "...#. ..#.. #...# #...# #...# #...# #...# #...# .###. ..... .....", // Ú
+	0xDB: "..#.. .#.#. #...# #...# #...# #...# #...# #...# .###. ..... .....", // Û
+	0xDC: ".#.#. ..... #...# #...# #...# #...# #...# #...# .###. ..... .....", // Ü
+	0xDD: "...#. ..#.. #...# #...# .#.#. ..#.. ..#.. ..#.. ..#.. ..... .....", // Ý
+	0xDE: "..... ..... #.... ####. #...# #...# ####. #.... #.... ..... .....", // Þ
+	0xDF: "..... ..... .##.. #..#. #..#. #.##. #...# #...# #.##. ..... .....", // ß
+	0xE0: "..... ..... .#... ..#.. .###. ....# .#### #...# .#### ..... .....", // à
+	0xE1: "..... ..... ...#. ..#.. .###. ....# .#### #...# .#### ..... .....", // á
+	0xE2: "..... ..... ..#.. .#.#. .###. ....# .#### #...# .#### ..... .....", // â
+	0xE3: "..... ..... .##.# #.##. .###. ....# .#### #...# .#### ..... .....", // ã
+	0xE4: "..... ..... .#.#. ..... .###. ....# .#### #...# .#### ..... .....", // ä
+	0xE5: "..... ..... ..#.. .#.#. .###. ....# .#### #...# .#### ..... .....", // å
+	0xE6: "..... ..... ..... ..... ##.#. ..#.# .#### #.#.. .#.## ..... .....", // æ
+	0xE7: "..... ..... ..... ..... .###. #.... #.... #...# .###. ..#.. .##..", // ç
+	0xE8: "..... ..... .#... ..#.. .###. #...# ##### #.... .###. ..... .....", // è
+	0xE9: "..... ..... ...#. ..#.. .###. #...# ##### #.... .###. ..... .....", // é
+	0xEA: "..... ..... ..#.. .#.#. .###. #...# ##### #.... .###. ..... .....", // ê
+	0xEB: "..... ..... .#.#. ..... .###. #...# ##### #.... .###. ..... .....", // ë
+	0xEC: "..... ..... .#... ..#.. .##.. ..#.. ..#.. ..#.. .###. ..... .....", // ì
+	0xED: "..... ..... ...#. ..#.. .##.. ..#.. ..#.. ..#.. .###. ..... .....", // í
+	0xEE: "..... ..... ..#.. .#.#. .##.. ..#.. ..#.. ..#.. .###. ..... .....", // î
+	0xEF: "..... ..... .#.#. ..... .##.. ..#.. ..#.. ..#.. .###. ..... .....", // ï
+	0xF0: "..... ..... .##.. ..##. .#.#. ....# .#### #...# .###. ..... .....", // ð
+	0xF1: "..... ..... .##.# #.##. #.##. ##..# #...# #...# #...# ..... .....", // ñ
+	0xF2: "..... ..... .#... ..#.. .###. #...# #...# #...# .###. ..... .....", // ò
+	0xF3: "..... ..... ...#. ..#.. .###. #...# #...# #...# .###. ..... .....", // ó
+	0xF4: "..... ..... ..#.. .#.#. .###. #...# #...# #...# .###. ..... .....", // ô
+	0xF5: "..... ..... .##.# #.##. .###. #...# #...# #...# .###. ..... .....", // õ
+	0xF6: "..... ..... .#.#. ..... .###. #...# #...# #...# .###. ..... .....", // ö
+	0xF7: "..... ..... ..... ..#.. ..... ##### ..... ..#.. ..... ..... .....", // ÷
+	0xF8: "..... ..... ..... ..... .###. #..## #.#.# ##..# .###. ..... .....", // ø
+	0xF9: "..... ..... .#... ..#.. #...# #...# #...# #..## .##.# ..... .....", // ù
+	0xFA: "..... ..... ...#. ..#.. #...# #...# #...# #..## .##.# ..... .....", // ú
+	0xFB: "..... ..... ..#.. .#.#. #...# #...# #...# #..## .##.# ..... .....", // û
+	0xFC: "..... ..... .#.#. ..... #...# #...# #...# #..## .##.# ..... .....", // ü
+	0xFD: "..... ..... ...#. ..#.. #...# #...# #...# #...# .#### ....# .###.", // ý
+	0xFE: "..... ..... #.... #.... ####. #...# #...# #...# ####. #.... #....", // þ
+	0xFF: "..... ..... .#.#. ..... #...# #...# #...# #...# .#### ....# .###.", // ÿ
+}
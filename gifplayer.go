@@ -0,0 +1,197 @@
+package gc9307
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"time"
+)
+
+// GIFPlayer plays an animated GIF. The frames are composed and converted to
+// RGB565 once, when the player is created, and each frame only keeps the
+// rectangle that differs from the frame before it, so playback is a plain
+// copy of the changed pixels to the bus.
+type GIFPlayer struct {
+	width, height int
+	frames        []gifFrame
+	restart       gifFrame // Changes from the last frame back to the first
+	loopCount     int
+}
+
+// gifFrame is the area of the canvas that changed with a frame
+type gifFrame struct {
+	rect  image.Rectangle
+	pix   []byte // RGB565 pixels of rect
+	delay time.Duration
+}
+
+// NewGIFPlayer prepares g for playback, applying each frame's disposal
+// method. Delays below 20 ms are played as 100 ms, like web browsers do.
+func NewGIFPlayer(g *gif.GIF) (*GIFPlayer, error) {
+	if len(g.Image) == 0 {
+		return nil, errors.New("gif: no frames")
+	}
+	w, h := g.Config.Width, g.Config.Height
+	if w == 0 || h == 0 {
+		// Some encoders leave the logical screen size out
+		var b image.Rectangle
+		for _, m := range g.Image {
+			b = b.Union(m.Bounds())
+		}
+		w, h = b.Max.X, b.Max.Y
+	}
+	p := &GIFPlayer{width: w, height: h, loopCount: g.LoopCount}
+
+	var bg color.Color = color.Transparent
+	if pal, ok := g.Config.ColorModel.(color.Palette); ok && int(g.BackgroundIndex) < len(pal) {
+		bg = pal[g.BackgroundIndex]
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	saved := image.NewRGBA(canvas.Bounds())
+	prev := make([]byte, w*h*2) // What the panel shows, in RGB565
+	cur := make([]byte, w*h*2)
+	var first []byte
+
+	for i, m := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			copy(saved.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, m.Bounds(), m, m.Bounds().Min, draw.Over)
+
+		image565(canvas, 0, w*h, cur)
+		f := gifFrame{rect: canvas.Bounds()}
+		if i > 0 {
+			f.rect = diffRect(prev, cur, w, h)
+		}
+		f.pix = crop565(cur, w, f.rect)
+		delay := 10
+		if i < len(g.Delay) && g.Delay[i] >= 2 {
+			delay = g.Delay[i]
+		}
+		f.delay = time.Duration(delay) * 10 * time.Millisecond
+		p.frames = append(p.frames, f)
+		if i == 0 {
+			first = append([]byte(nil), cur...)
+		}
+		prev, cur = cur, prev
+
+		// Prepare the canvas for the next frame
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, m.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, saved.Pix)
+		}
+	}
+
+	p.restart = p.frames[0]
+	p.restart.rect = diffRect(prev, first, w, h)
+	p.restart.pix = crop565(first, w, p.restart.rect)
+	return p, nil
+}
+
+// Bounds returns the size of the animation.
+func (p *GIFPlayer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, p.width, p.height)
+}
+
+// Len returns the number of frames.
+func (p *GIFPlayer) Len() int {
+	return len(p.frames)
+}
+
+// Play shows the animation with its top left corner at (x, y), clipped to
+// the display, as many times as the GIF's loop count says: forever for 0,
+// once for -1 and LoopCount+1 times otherwise. It returns ctx.Err() when the
+// context is done, nil when the animation ends.
+//
+// Frames are scheduled from the start time, so a frame that takes too long
+// to send shortens the next delay instead of slowing the animation down.
+func (p *GIFPlayer) Play(ctx context.Context, d *Device, x, y int16) error {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	plays := p.loopCount + 1
+	if p.loopCount < 0 {
+		plays = 1
+	}
+	next := time.Now()
+	for loop := 0; p.loopCount == 0 || loop < plays; loop++ {
+		for i, f := range p.frames {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if i == 0 && loop > 0 {
+				f = p.restart
+			}
+			if !f.rect.Empty() {
+				r := f.rect.Add(image.Pt(int(x), int(y)))
+				if err := d.drawRGB565(r.Min.X, r.Min.Y, r.Dx(), r.Dy(), f.pix); err != nil {
+					return err
+				}
+			}
+
+			next = next.Add(f.delay)
+			if wait := time.Until(next); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			} else {
+				// Too late already, don't try to catch up with a burst
+				next = time.Now()
+			}
+		}
+	}
+	return nil
+}
+
+// diffRect returns the bounding box of the pixels that differ between two
+// w x h RGB565 images
+func diffRect(a, b []byte, w, h int) image.Rectangle {
+	r := image.Rectangle{Min: image.Pt(w, h)}
+	for y := 0; y < h; y++ {
+		row := y * w * 2
+		for x := 0; x < w; x++ {
+			i := row + x*2
+			if a[i] == b[i] && a[i+1] == b[i+1] {
+				continue
+			}
+			if x < r.Min.X {
+				r.Min.X = x
+			}
+			if x >= r.Max.X {
+				r.Max.X = x + 1
+			}
+			if y < r.Min.Y {
+				r.Min.Y = y
+			}
+			r.Max.Y = y + 1
+		}
+	}
+	if r.Max.X == 0 {
+		return image.Rectangle{}
+	}
+	return r
+}
+
+// crop565 copies rect out of a w pixels wide RGB565 image
+func crop565(pix []byte, w int, rect image.Rectangle) []byte {
+	out := make([]byte, 0, rect.Dx()*rect.Dy()*2)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		i := (y*w + rect.Min.X) * 2
+		out = append(out, pix[i:i+rect.Dx()*2]...)
+	}
+	return out
+}
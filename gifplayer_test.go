@@ -0,0 +1,145 @@
+package gc9307
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+// Palette of the test animations, black first for the background
+var gifPalette = color.Palette{
+	color.RGBA{0, 0, 0, 255},
+	color.RGBA{255, 0, 0, 255},
+	color.RGBA{0, 0, 255, 255},
+	color.RGBA{0, 255, 0, 255},
+	color.RGBA{},
+}
+
+// gifRect returns a frame covering r in the palette color index i.
+func gifRect(r image.Rectangle, i uint8) *image.Paletted {
+	m := image.NewPaletted(r, gifPalette)
+	for j := range m.Pix {
+		m.Pix[j] = i
+	}
+	return m
+}
+
+// gifPix returns the RGB565 pixels of rows, strings of palette indexes.
+func gifPix(rows ...string) []byte {
+	var pix []byte
+	for _, row := range rows {
+		for _, c := range row {
+			v := RGBATo565BGR(gifPalette[c-'0'].(color.RGBA))
+			pix = append(pix, uint8(v>>8), uint8(v))
+		}
+	}
+	return pix
+}
+
+// disposalGIF is a 4x4 red frame, then a blue square disposed to the
+// background, a green pixel disposed to the previous frame and a
+// transparent pixel.
+func disposalGIF() *gif.GIF {
+	return &gif.GIF{
+		Image: []*image.Paletted{
+			gifRect(image.Rect(0, 0, 4, 4), 1),
+			gifRect(image.Rect(1, 1, 3, 3), 2),
+			gifRect(image.Rect(0, 0, 1, 1), 3),
+			gifRect(image.Rect(3, 3, 4, 4), 4),
+		},
+		Delay:    []int{0, 5, 2},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious},
+		Config:   image.Config{ColorModel: gifPalette, Width: 4, Height: 4},
+	}
+}
+
+func TestGIFPlayerDisposal(t *testing.T) {
+	p, err := NewGIFPlayer(disposalGIF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gifFrame{
+		{image.Rect(0, 0, 4, 4), gifPix("1111", "1111", "1111", "1111"), 100 * time.Millisecond},
+		{image.Rect(1, 1, 3, 3), gifPix("22", "22"), 50 * time.Millisecond},
+		// The blue square was cleared to the background
+		{image.Rect(0, 0, 3, 3), gifPix("311", "100", "100"), 20 * time.Millisecond},
+		// The green pixel is gone again, the transparent one changes nothing
+		{image.Rect(0, 0, 1, 1), gifPix("1"), 100 * time.Millisecond},
+	}
+	if p.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", p.Len(), len(want))
+	}
+	for i, f := range p.frames {
+		w := want[i]
+		if f.rect != w.rect || !bytes.Equal(f.pix, w.pix) || f.delay != w.delay {
+			t.Errorf("frame %d: %v %x %v, want %v %x %v", i, f.rect, f.pix, f.delay, w.rect, w.pix, w.delay)
+		}
+	}
+	// Looping back only redraws the square left black
+	if r := p.restart; r.rect != image.Rect(1, 1, 3, 3) || !bytes.Equal(r.pix, gifPix("11", "11")) {
+		t.Errorf("restart: %v %x", r.rect, r.pix)
+	}
+}
+
+func TestNewGIFPlayer(t *testing.T) {
+	if _, err := NewGIFPlayer(&gif.GIF{}); err == nil {
+		t.Error("no error without frames")
+	}
+
+	// Without a logical screen size, the frames set it; frames reaching
+	// outside of it are clipped
+	g := &gif.GIF{Image: []*image.Paletted{
+		gifRect(image.Rect(1, 0, 3, 2), 1),
+		gifRect(image.Rect(2, 1, 6, 6), 2),
+	}}
+	p, err := NewGIFPlayer(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := p.Bounds(); b != image.Rect(0, 0, 6, 6) {
+		t.Errorf("Bounds() = %v", b)
+	}
+	g.Config = image.Config{ColorModel: gifPalette, Width: 3, Height: 2}
+	if p, err = NewGIFPlayer(g); err != nil {
+		t.Fatal(err)
+	}
+	if r := p.frames[1].rect; r != image.Rect(2, 1, 3, 2) {
+		t.Errorf("clipped frame: %v", r)
+	}
+}
+
+func TestGIFPlayerPlay(t *testing.T) {
+	g := disposalGIF()
+	g.Delay = []int{2, 2, 2, 2}
+	g.LoopCount = 1 // Played twice
+	p, err := NewGIFPlayer(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, r := recorderDevice(t)
+	if err := p.Play(context.Background(), d, 10, 10); err != nil {
+		t.Fatal(err)
+	}
+	var frames [][]byte
+	for _, op := range r.Ops {
+		if op.Kind == OpPixels {
+			frames = append(frames, op.Data)
+		}
+	}
+	if len(frames) != 8 {
+		t.Fatalf("sent %d frames, want 8", len(frames))
+	}
+	if !bytes.Equal(frames[0], p.frames[0].pix) || !bytes.Equal(frames[4], p.restart.pix) {
+		t.Errorf("the second loop does not start with the restart frame")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Play(ctx, d, 0, 0); err != context.Canceled {
+		t.Errorf("Play() with a canceled context = %v", err)
+	}
+}
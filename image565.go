@@ -0,0 +1,134 @@
+package gc9307
+
+import (
+	"image"
+	"image/color"
+)
+
+// RGB565Image is an image stored in the panel's own pixel format: big endian
+// RGB565 with red and blue swapped, as RGBATo565BGR. Drawing it copies the
+// pixels without any color conversion.
+type RGB565Image struct {
+	Pix    []byte
+	Stride int // Bytes per row
+	Rect   image.Rectangle
+}
+
+// NewRGB565Image returns a black image with the given bounds.
+func NewRGB565Image(r image.Rectangle) *RGB565Image {
+	return &RGB565Image{
+		Pix:    make([]byte, r.Dx()*r.Dy()*2),
+		Stride: r.Dx() * 2,
+		Rect:   r,
+	}
+}
+
+// ColorModel returns color.RGBAModel, the type of the colors At returns.
+func (p *RGB565Image) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds returns the image bounds.
+func (p *RGB565Image) Bounds() image.Rectangle { return p.Rect }
+
+// At returns the pixel at (x, y), expanded to 8 bits per channel.
+func (p *RGB565Image) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA{}
+	}
+	i := p.PixOffset(x, y)
+	v := uint16(p.Pix[i])<<8 | uint16(p.Pix[i+1])
+	r, g, b := uint8(v&0x1F), uint8(v>>5&0x3F), uint8(v>>11)
+	return color.RGBA{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 0xFF}
+}
+
+// PixOffset returns the index of the first byte of the pixel at (x, y).
+func (p *RGB565Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+// bgr565 packs 8 bit channels like RGBATo565BGR
+func bgr565(r, g, b uint8) uint16 {
+	return uint16(b>>3)<<11 | uint16(g>>2)<<5 | uint16(r>>3)
+}
+
+// imageRow565 converts n pixels of img, starting at (x, y) relative to the
+// image bounds, to big endian RGB565 in dst. The common image types are read
+// straight from their Pix slices; anything else goes through At.
+func imageRow565(img image.Image, x, y, n int, dst []byte) {
+	b := img.Bounds()
+	x += b.Min.X
+	y += b.Min.Y
+	switch m := img.(type) {
+	case *RGB565Image:
+		i := m.PixOffset(x, y)
+		copy(dst[:n*2], m.Pix[i:i+n*2])
+	case *image.RGBA:
+		p := m.Pix[m.PixOffset(x, y):]
+		for i := 0; i < n; i++ {
+			put565(dst[i*2:], bgr565(p[i*4], p[i*4+1], p[i*4+2]))
+		}
+	case *image.NRGBA:
+		p := m.Pix[m.PixOffset(x, y):]
+		for i := 0; i < n; i++ {
+			r, g, b, a := p[i*4], p[i*4+1], p[i*4+2], p[i*4+3]
+			if a != 0xFF {
+				// Premultiply with the same rounding as color.NRGBA.RGBA
+				a16 := uint32(a) * 0x101
+				r = uint8(uint32(r) * 0x101 * a16 / 0xFFFF >> 8)
+				g = uint8(uint32(g) * 0x101 * a16 / 0xFFFF >> 8)
+				b = uint8(uint32(b) * 0x101 * a16 / 0xFFFF >> 8)
+			}
+			put565(dst[i*2:], bgr565(r, g, b))
+		}
+	case *image.YCbCr:
+		yi := m.YOffset(x, y)
+		for i := 0; i < n; i++ {
+			ci := m.COffset(x+i, y)
+			r, g, b := color.YCbCrToRGB(m.Y[yi+i], m.Cb[ci], m.Cr[ci])
+			put565(dst[i*2:], bgr565(r, g, b))
+		}
+	case *image.Gray:
+		p := m.Pix[m.PixOffset(x, y):]
+		for i := 0; i < n; i++ {
+			put565(dst[i*2:], bgr565(p[i], p[i], p[i]))
+		}
+	case *image.Paletted:
+		// Convert each palette entry once, when first used
+		var lut [256]uint16
+		var known [256]bool
+		p := m.Pix[m.PixOffset(x, y):]
+		for i := 0; i < n; i++ {
+			k := p[i]
+			if !known[k] {
+				var c color.RGBA
+				if int(k) < len(m.Palette) {
+					c = color.RGBAModel.Convert(m.Palette[k]).(color.RGBA)
+				}
+				lut[k], known[k] = RGBATo565BGR(c), true
+			}
+			put565(dst[i*2:], lut[k])
+		}
+	default:
+		for i := 0; i < n; i++ {
+			c := color.RGBAModel.Convert(img.At(x+i, y)).(color.RGBA)
+			put565(dst[i*2:], RGBATo565BGR(c))
+		}
+	}
+}
+
+// image565 converts n pixels of img to RGB565 in dst, starting at pixel
+// offset of the image in row-major order, relative to its bounds, and
+// wrapping to the next rows as needed
+func image565(img image.Image, offset, n int, dst []byte) {
+	width := img.Bounds().Dx()
+	for n > 0 {
+		row, col := offset/width, offset%width
+		run := width - col
+		if run > n {
+			run = n
+		}
+		imageRow565(img, col, row, run, dst)
+		dst = dst[run*2:]
+		offset += run
+		n -= run
+	}
+}
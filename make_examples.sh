@@ -47,6 +47,9 @@ if [ "$HOST_ARCH" = "x86_64" ]; then
     
     echo "Building color benchmark..."
     cd examples/color_benchmark && env $BUILD_ENV CC=$CC go build -buildvcs=false -o ../../gc9307_color_benchmark . && cd ../..
+
+    echo "Building gc9307ctl..."
+    cd cmd/gc9307ctl && env $BUILD_ENV CC=$CC go build -buildvcs=false -o ../../gc9307ctl . && cd ../..
 else
     echo -e "\n${YELLOW}Native compilation for ARM...${NC}"
     # Native compile on ARM system
@@ -58,6 +61,9 @@ else
     
     echo "Building color benchmark..."
     cd examples/color_benchmark && go build -buildvcs=false -o ../../gc9307_color_benchmark . && cd ../..
+
+    echo "Building gc9307ctl..."
+    cd cmd/gc9307ctl && go build -buildvcs=false -o ../../gc9307ctl . && cd ../..
 fi
 
 if [ $? -eq 0 ]; then
@@ -67,6 +73,7 @@ if [ $? -eq 0 ]; then
     echo "- ${BLUE}./gc9307_sample${NC}  - Basic sample program"
     echo "- ${BLUE}./gc9307_benchmark${NC} - Performance benchmark with 3x3 panning"
     echo "- ${BLUE}./gc9307_color_benchmark${NC} - Color conversion performance test"
+    echo "- ${BLUE}./gc9307ctl${NC} - Command-line tool to operate the panel"
     echo ""
     echo "Sample Program Features:"
     echo "- Basic GC9307 display initialization"
@@ -83,7 +90,8 @@ if [ $? -eq 0 ]; then
     echo "- Zero-allocation frame rendering (pre-allocated buffers)"
     echo "- DMA-optimized transfers (enabled by default)"
     echo "- Configurable area size, duration and DMA mode"
-    echo "- Usage: ./gc9307_benchmark [-nodma] [-duration=30] [-area=50]"
+    echo "- Small rectangle throughput mode with optional packet batching"
+    echo "- Usage: ./gc9307_benchmark [-nodma] [-nopackets] [-rect=8] [-duration=30] [-area=50]"
     echo "- Area sizes: 20% to 100% of display (default: 50%)"
     echo ""
     echo "Color Benchmark Features:"
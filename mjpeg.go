@@ -0,0 +1,225 @@
+package gc9307
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"sync/atomic"
+)
+
+// MJPEGReader splits a Motion JPEG stream into its JPEG frames. It accepts
+// raw concatenated JPEGs as well as multipart/x-mixed-replace streams as sent
+// by IP cameras: anything between frames, such as boundaries and part
+// headers, is skipped.
+type MJPEGReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewMJPEGReader returns a reader of the frames in r.
+func NewMJPEGReader(r io.Reader) *MJPEGReader {
+	return &MJPEGReader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next returns the next JPEG frame, from its SOI marker to its EOI marker.
+// The slice is only valid until the next call. It returns io.EOF when the
+// stream ends between frames.
+func (m *MJPEGReader) Next() ([]byte, error) {
+	// Skip to the start of image marker
+	var prev byte
+	for {
+		b, err := m.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if prev == 0xFF && b == 0xD8 {
+			break
+		}
+		prev = b
+	}
+	m.buf = append(m.buf[:0], 0xFF, 0xD8)
+
+	// Walk the marker segments, so that bytes in headers and thumbnails
+	// aren't taken for the end of the image
+	code, err := m.marker()
+	for err == nil {
+		m.buf = append(m.buf, 0xFF, code)
+		switch {
+		case code == 0xD9: // EOI
+			return m.buf, nil
+		case code == 0x01 || code >= 0xD0 && code <= 0xD7: // No payload
+			code, err = m.marker()
+		default:
+			if err = m.segment(); err != nil {
+				break
+			}
+			if code == 0xDA { // SOS, entropy coded data follows
+				code, err = m.scan()
+			} else {
+				code, err = m.marker()
+			}
+		}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, err
+}
+
+// marker reads the next marker and returns its code
+func (m *MJPEGReader) marker() (byte, error) {
+	b, err := m.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, errors.New("mjpeg: expected a JPEG marker")
+	}
+	for b == 0xFF {
+		// Any number of fill bytes may precede the code
+		if b, err = m.r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// segment copies a marker segment's length and payload
+func (m *MJPEGReader) segment() error {
+	var l [2]byte
+	if _, err := io.ReadFull(m.r, l[:]); err != nil {
+		return err
+	}
+	n := int(l[0])<<8 | int(l[1])
+	if n < 2 {
+		return errors.New("mjpeg: invalid segment length")
+	}
+	m.buf = append(m.buf, l[0], l[1])
+	start := len(m.buf)
+	for cap(m.buf)-start < n-2 {
+		m.buf = append(m.buf[:cap(m.buf)], 0)
+	}
+	m.buf = m.buf[:start+n-2]
+	_, err := io.ReadFull(m.r, m.buf[start:])
+	return err
+}
+
+// scan copies entropy coded data and returns the code of the marker ending it
+func (m *MJPEGReader) scan() (byte, error) {
+	for {
+		b, err := m.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != 0xFF {
+			m.buf = append(m.buf, b)
+			continue
+		}
+		c := byte(0xFF)
+		for c == 0xFF {
+			if c, err = m.r.ReadByte(); err != nil {
+				return 0, err
+			}
+		}
+		if c == 0x00 || c >= 0xD0 && c <= 0xD7 {
+			// Stuffed 0xFF or restart marker, still part of the scan
+			m.buf = append(m.buf, 0xFF, c)
+			continue
+		}
+		return c, nil
+	}
+}
+
+// MJPEGOptions configures PlayMJPEG.
+type MJPEGOptions struct {
+	Rect   image.Rectangle // Where frames are drawn; the whole display when empty
+	Mode   ScaleMode
+	Filter Filter
+}
+
+// PlayMJPEG shows the frames of a Motion JPEG stream, see MJPEGReader, as
+// fast as they can be decoded and drawn, scaled into opts.Rect with
+// DrawImageFit. Baseline JPEGs decode to *image.YCbCr, which is converted to
+// RGB565 row by row without an RGBA copy of the frame.
+//
+// The stream is read in the background. When decoding falls behind, only
+// the newest complete frame is kept and the older ones are counted in
+// VideoStats.Dropped, so a live source is never shown late. Frames that fail
+// to decode are dropped too.
+//
+// It returns when the stream ends or ctx is done. In the latter case the
+// background reader stops once its pending Read returns; close r to make
+// that happen right away.
+func (d *Device) PlayMJPEG(ctx context.Context, r io.Reader, opts MJPEGOptions) (VideoStats, error) {
+	var stats VideoStats
+	area := opts.Rect
+	if area.Empty() {
+		area = d.bounds()
+	}
+
+	type frame struct {
+		data []byte
+		err  error
+	}
+	latest := make(chan frame, 1)
+	done := make(chan struct{})
+	defer close(done)
+	var dropped int64
+
+	go func() {
+		mr := NewMJPEGReader(r)
+		for {
+			data, err := mr.Next()
+			f := frame{data: append([]byte(nil), data...), err: err}
+			// Replace the frame that wasn't picked up in time, but let the
+			// last one be shown before the end of the stream is reported
+			if err == nil {
+				select {
+				case <-latest:
+					atomic.AddInt64(&dropped, 1)
+				default:
+				}
+			}
+			select {
+			case latest <- f:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var f frame
+		select {
+		case <-ctx.Done():
+			stats.Dropped += int(atomic.LoadInt64(&dropped))
+			return stats, ctx.Err()
+		case f = <-latest:
+		}
+		if f.err != nil {
+			if f.err == io.EOF {
+				f.err = nil
+			}
+			stats.Dropped += int(atomic.LoadInt64(&dropped))
+			return stats, f.err
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(f.data))
+		if err != nil {
+			stats.Dropped++
+			continue
+		}
+		if err := d.DrawImageFit(area, img, opts.Mode, opts.Filter); err != nil {
+			stats.Dropped += int(atomic.LoadInt64(&dropped))
+			return stats, err
+		}
+		stats.Frames++
+	}
+}
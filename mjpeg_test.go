@@ -0,0 +1,117 @@
+package gc9307
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+// mjpegFrame is a JPEG skeleton whose header and scan data hold the bytes
+// that could be mistaken for markers: an EOI in an APP0 payload, fill
+// bytes, a stuffed 0xFF and a restart marker.
+var mjpegFrame = []byte{
+	0xFF, 0xD8, // SOI
+	0xFF, 0xE0, 0x00, 0x06, 0xFF, 0xD9, 0xFF, 0xD8, // APP0
+	0xFF, 0xFF, 0xDB, 0x00, 0x03, 0x01, // DQT after fill bytes
+	0xFF, 0xDA, 0x00, 0x02, // SOS
+	0x12, 0xFF, 0x00, 0x34, 0xFF, 0xD0, 0x56, // Scan
+	0xFF, 0xD9, // EOI
+}
+
+// mjpegWant is mjpegFrame as returned by Next, without the fill bytes.
+var mjpegWant = append(append([]byte{}, mjpegFrame[:10]...), mjpegFrame[11:]...)
+
+func TestMJPEGReader(t *testing.T) {
+	part := "--frame\r\nContent-Type: image/jpeg\r\n\r\n"
+	for _, tc := range []struct {
+		name   string
+		stream []byte
+		frames int
+	}{
+		{"Empty", nil, 0},
+		{"Raw", bytes.Repeat(mjpegFrame, 2), 2},
+		{"Multipart", []byte(part + string(mjpegFrame) + "\r\n" + part + string(mjpegFrame) + "\r\n--frame--\r\n"), 2},
+		{"TrailingJunk", append(append([]byte{}, mjpegFrame...), "\xFF\x00junk"...), 1},
+	} {
+		m := NewMJPEGReader(bytes.NewReader(tc.stream))
+		for i := 0; i < tc.frames; i++ {
+			f, err := m.Next()
+			if err != nil {
+				t.Fatalf("%s: frame %d: %v", tc.name, i, err)
+			}
+			if !bytes.Equal(f, mjpegWant) {
+				t.Errorf("%s: frame %d is % X", tc.name, i, f)
+			}
+		}
+		if _, err := m.Next(); err != io.EOF {
+			t.Errorf("%s: Next() after the frames = %v, want io.EOF", tc.name, err)
+		}
+	}
+}
+
+func TestMJPEGReaderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stream []byte
+		want   error // nil for any error
+	}{
+		{"AfterSOI", mjpegFrame[:2], io.ErrUnexpectedEOF},
+		{"InSegmentLength", mjpegFrame[:5], io.ErrUnexpectedEOF},
+		{"InSegment", mjpegFrame[:8], io.ErrUnexpectedEOF},
+		{"InFillBytes", mjpegFrame[:11], io.ErrUnexpectedEOF},
+		{"InScan", mjpegFrame[:21], io.ErrUnexpectedEOF},
+		{"AfterStuffedFF", mjpegFrame[:23], io.ErrUnexpectedEOF},
+		{"BeforeEOI", mjpegFrame[:len(mjpegFrame)-1], io.ErrUnexpectedEOF},
+		{"NoMarker", []byte{0xFF, 0xD8, 0x00}, nil},
+		{"SegmentLength", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01}, nil},
+	} {
+		m := NewMJPEGReader(bytes.NewReader(tc.stream))
+		_, err := m.Next()
+		if err == nil || tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// After a malformed frame, the reader starts over at the next one
+	stream := append([]byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01}, mjpegFrame...)
+	m := NewMJPEGReader(bytes.NewReader(stream))
+	if _, err := m.Next(); err == nil {
+		t.Fatal("no error for a malformed frame")
+	}
+	if f, err := m.Next(); err != nil || !bytes.Equal(f, mjpegWant) {
+		t.Errorf("frame after the error: % X, %v", f, err)
+	}
+}
+
+func TestPlayMJPEG(t *testing.T) {
+	var stream bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	if err := jpeg.Encode(&stream, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	// A frame that is split correctly but does not decode
+	stream.Write(mjpegFrame)
+
+	d, r := recorderDevice(t)
+	stats, err := d.PlayMJPEG(context.Background(), &stream, MJPEGOptions{Rect: image.Rect(0, 0, 16, 16)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Frames+stats.Dropped != 2 || stats.Dropped < 1 {
+		t.Errorf("stats %+v, want 2 frames in all, the second dropped", stats)
+	}
+	if stats.Frames == 1 {
+		white := RGBATo565BGR(color.RGBA{255, 255, 255, 255})
+		if pix := sentPixels(r); len(pix) != 16*16*2 || pix[0] != uint8(white>>8) {
+			t.Errorf("sent %d bytes of pixels", len(pix))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package gc9307
+
+import (
+	"math"
+
+	"periph.io/x/conn/v3"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/spi"
+)
+
+// maxPackets limits the number of packets sent in one TxPackets call. spidev
+// accepts at most 511 transfers per message, keep well below that.
+const maxPackets = 64
+
+// defaultMaxPacketBytes is the spidev default bufsiz, used when the bus does
+// not report its own limit.
+const defaultMaxPacketBytes = 4096
+
+// DCPacketConn is implemented by SPI connections that can drive the DC line
+// themselves between the packets of a single TxPackets call, for example a
+// transport with a hardware DC output. When the bus passed to New implements
+// it, the window setup and the following pixel data go out in one call.
+//
+// No periph.io connection implements it: with spidev and a GPIO DC pin, DC
+// can only change between calls, so every command and every parameter block
+// still costs a transfer of its own. Only the pixel data is batched then,
+// which helps large fills but not small updates; for those only the window
+// cache saves calls.
+type DCPacketConn interface {
+	spi.Conn
+	// TxPacketsDC sends p as one transaction, setting DC to dc[i] before p[i].
+	TxPacketsDC(p []spi.Packet, dc []gpio.Level) error
+}
+
+// busLimit returns the maximum number of bytes to send per TxPackets call:
+// what the bus accepts in one message, the spidev bufsiz, lowered to
+// maxTransferSize when that is set and smaller
+func busLimit(bus spi.Conn, maxTransferSize int32) int32 {
+	limit := int32(defaultMaxPacketBytes)
+	if l, ok := bus.(conn.Limits); ok {
+		if n := l.MaxTxSize(); n > 0 && n < math.MaxInt32 {
+			limit = int32(n)
+		}
+	}
+	if maxTransferSize > 0 && maxTransferSize < limit {
+		limit = maxTransferSize
+	}
+	return limit
+}
+
+// packetQueue collects packets to send in a single TxPackets call
+type packetQueue struct {
+	packets []spi.Packet
+	dc      []gpio.Level // DC level of each packet
+	bytes   int32
+	limit   int32
+	store   []uint8 // Copies of queued command bytes
+}
+
+// full reports whether the queue must be flushed before adding n bytes with
+// the given DC level. mixedDC tells if the flush can switch DC per packet.
+func (q *packetQueue) full(n int, dc gpio.Level, mixedDC bool) bool {
+	l := len(q.packets)
+	return l > 0 && (l >= maxPackets ||
+		q.bytes+int32(n) > q.limit ||
+		(!mixedDC && q.dc[l-1] != dc))
+}
+
+// add queues w, which must not be modified until the queue is flushed
+func (q *packetQueue) add(w []byte, dc gpio.Level) {
+	q.packets = append(q.packets, spi.Packet{W: w, KeepCS: true})
+	q.dc = append(q.dc, dc)
+	q.bytes += int32(len(w))
+}
+
+// addCopy queues a copy of w, so the caller can reuse its buffer
+func (q *packetQueue) addCopy(w []byte, dc gpio.Level) {
+	start := len(q.store)
+	q.store = append(q.store, w...)
+	q.add(q.store[start:len(q.store):len(q.store)], dc)
+}
+
+// split calls add with consecutive pieces of w no longer than the queue
+// limit, cut at even offsets so that no pixel straddles two transfers
+func (q *packetQueue) split(w []byte, add func(w []byte) error) error {
+	n := int(q.limit) &^ 1
+	if n <= 0 {
+		n = 2
+	}
+	for len(w) > n {
+		if err := add(w[:n]); err != nil {
+			return err
+		}
+		w = w[n:]
+	}
+	return add(w)
+}
+
+// last returns the queued packets, with CS released after the last one
+func (q *packetQueue) last() []spi.Packet {
+	if n := len(q.packets); n > 0 {
+		q.packets[n-1].KeepCS = false
+	}
+	return q.packets
+}
+
+// reset empties the queue, dropping references to the callers' buffers but
+// keeping the capacity
+func (q *packetQueue) reset() {
+	for i := range q.packets {
+		q.packets[i] = spi.Packet{}
+	}
+	q.packets = q.packets[:0]
+	q.dc = q.dc[:0]
+	q.store = q.store[:0]
+	q.bytes = 0
+}
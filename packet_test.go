@@ -0,0 +1,179 @@
+package gc9307
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"periph.io/x/conn/v3"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/spi"
+)
+
+// countingBus is a fake spi.Conn that counts what is sent through it.
+type countingBus struct {
+	maxTxSize int // Reported through conn.Limits, 0 for none
+	calls     int // Tx and TxPackets calls
+	packets   int
+	bytes     int
+	largest   int // Most bytes sent in one call
+}
+
+func (b *countingBus) String() string      { return "countingBus" }
+func (b *countingBus) Duplex() conn.Duplex { return conn.Full }
+func (b *countingBus) MaxTxSize() int      { return b.maxTxSize }
+
+func (b *countingBus) Tx(w, r []byte) error {
+	return b.TxPackets([]spi.Packet{{W: w, R: r}})
+}
+
+func (b *countingBus) TxPackets(p []spi.Packet) error {
+	n := 0
+	for _, pk := range p {
+		n += len(pk.W)
+	}
+	b.calls++
+	b.packets += len(p)
+	b.bytes += n
+	if n > b.largest {
+		b.largest = n
+	}
+	return nil
+}
+
+func (b *countingBus) reset() {
+	b.calls, b.packets, b.bytes, b.largest = 0, 0, 0, 0
+}
+
+// dcBus is a countingBus that switches DC between packets itself.
+type dcBus struct {
+	countingBus
+}
+
+func (b *dcBus) TxPacketsDC(p []spi.Packet, dc []gpio.Level) error {
+	return b.TxPackets(p)
+}
+
+var _ DCPacketConn = &dcBus{}
+
+// configureTest configures d with cfg, keeping the marker of an initialized
+// panel in a temporary directory. With initialized, the panel is taken as
+// initialized since boot and not reset.
+func configureTest(tb testing.TB, d *Device, cfg Config, initialized bool) {
+	tb.Helper()
+	saved := initializedFile
+	tb.Cleanup(func() { initializedFile = saved })
+	initializedFile = filepath.Join(tb.TempDir(), "initialized")
+	if initialized {
+		if err := os.WriteFile(initializedFile, nil, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	d.Configure(cfg)
+}
+
+func TestBusLimit(t *testing.T) {
+	for _, tc := range []struct {
+		maxTxSize       int
+		maxTransferSize int32
+		want            int32
+	}{
+		{0, 0, defaultMaxPacketBytes},
+		{0, 65536, defaultMaxPacketBytes},
+		{4096, 65536, 4096},
+		{65536, 65536, 65536},
+		{8192, 2048, 2048},
+		{1 << 40, 0, defaultMaxPacketBytes},
+	} {
+		bus := &countingBus{maxTxSize: tc.maxTxSize}
+		if got := busLimit(bus, tc.maxTransferSize); got != tc.want {
+			t.Errorf("busLimit(MaxTxSize %d, %d) = %d, want %d", tc.maxTxSize, tc.maxTransferSize, got, tc.want)
+		}
+	}
+}
+
+// Pixel data larger than the bus accepts in one message is split, even when
+// the DMA transfer size is larger.
+func TestWritePixelsSplit(t *testing.T) {
+	pix := make([]byte, 10002)
+	for _, tc := range []struct {
+		name string
+		t    func(bus spi.Conn) Transport
+	}{
+		{"FourWire", func(bus spi.Conn) Transport { return NewFourWire(bus, gpio.INVALID) }},
+		{"ThreeWire", func(bus spi.Conn) Transport { return NewThreeWire(bus, false) }},
+		{"ThreeWire9Bit", func(bus spi.Conn) Transport { return NewThreeWire(bus, true) }},
+	} {
+		for _, usePackets := range []bool{true, false} {
+			bus := &countingBus{maxTxSize: 4096}
+			tr := tc.t(bus)
+			tr.(configurable).configure(transportConfig{usePackets: usePackets, maxTransferSize: 65536})
+			if err := tr.WritePixels(pix, 2); err != nil {
+				t.Fatal(err)
+			}
+			if bus.largest > 4096 {
+				t.Errorf("%s, packets %t: %d bytes in one call, limit 4096", tc.name, usePackets, bus.largest)
+			}
+			if tc.name == "FourWire" && bus.bytes != 2*len(pix) {
+				t.Errorf("%s, packets %t: sent %d bytes, want %d", tc.name, usePackets, bus.bytes, 2*len(pix))
+			}
+		}
+	}
+}
+
+// The benchmarks count bus calls: on spidev every call is a system call,
+// which costs far more than building the packets, so calls/op is what
+// decides the throughput on the panel.
+
+// BenchmarkSmallRect fills 8x8 rectangles at changing positions, so that
+// every one needs a full window setup.
+func BenchmarkSmallRect(b *testing.B) {
+	benchmarkBuses(b, func(d *Device, i int) {
+		d.FillRectangle(int16(i%21)*8, int16(i%40)*8, 8, 8, color.RGBA{255, 0, 0, 255})
+	})
+}
+
+// BenchmarkSmallRectRow fills 8x8 rectangles along a row, so the window
+// cache skips RASET.
+func BenchmarkSmallRectRow(b *testing.B) {
+	benchmarkBuses(b, func(d *Device, i int) {
+		d.FillRectangle(int16(i%21)*8, 0, 8, 8, color.RGBA{255, 0, 0, 255})
+	})
+}
+
+// BenchmarkFillScreen fills the whole 172x320 screen.
+func BenchmarkFillScreen(b *testing.B) {
+	benchmarkBuses(b, func(d *Device, i int) {
+		d.FillScreen(color.RGBA{uint8(i), 0, 0, 255})
+	})
+}
+
+func benchmarkBuses(b *testing.B, draw func(d *Device, i int)) {
+	for _, bc := range []struct {
+		name      string
+		noPackets bool
+		dc        bool
+	}{
+		{"NoPackets", true, false},
+		{"Packets", false, false},
+		{"DCPacketConn", false, true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var bus spi.Conn = &countingBus{}
+			counter := bus.(*countingBus)
+			if bc.dc {
+				db := &dcBus{}
+				bus, counter = db, &db.countingBus
+			}
+			d := New(bus, gpio.INVALID, gpio.INVALID, nil, gpio.INVALID)
+			configureTest(b, &d, Config{Width: 172, Height: 320, NoPackets: bc.noPackets}, true)
+			counter.reset()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				draw(&d, i)
+			}
+			b.ReportMetric(float64(counter.calls)/float64(b.N), "calls/op")
+		})
+	}
+}
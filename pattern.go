@@ -0,0 +1,223 @@
+package gc9307
+
+import (
+	"errors"
+	"image/color"
+	"strconv"
+)
+
+// Pattern is a built-in test pattern drawn by DrawPattern.
+type Pattern uint8
+
+// Test patterns
+const (
+	PatternColorBars    Pattern = iota // Eight vertical bars: white, yellow, cyan, green, magenta, red, blue, black
+	PatternGrid                        // Gray lines every 10 pixels, white every 50, and a white frame
+	PatternEdges                       // Colored outermost rows and columns and a TOP label, to check offsets and rotation
+	PatternGradient                    // Gray ramp above, red to green across and blue down below
+	PatternCheckerboard                // 8 pixel black and white squares
+	PatternRamps                       // Red, green, blue and gray ramps, one band each
+)
+
+var patternNames = []string{"bars", "grid", "edges", "gradient", "checker", "ramps"}
+
+func (p Pattern) String() string {
+	if int(p) < len(patternNames) {
+		return patternNames[p]
+	}
+	return "Pattern(" + strconv.Itoa(int(p)) + ")"
+}
+
+// ParsePattern returns the pattern named as by Pattern.String.
+func ParsePattern(name string) (Pattern, error) {
+	for i, n := range patternNames {
+		if n == name {
+			return Pattern(i), nil
+		}
+	}
+	return 0, errors.New("unknown test pattern " + strconv.Quote(name))
+}
+
+// Edge colors of PatternEdges, also used by DrawOffsetMarkers
+var (
+	edgeTop    = color.RGBA{255, 0, 0, 255}
+	edgeRight  = color.RGBA{0, 255, 0, 255}
+	edgeBottom = color.RGBA{0, 0, 255, 255}
+	edgeLeft   = color.RGBA{255, 255, 0, 255}
+)
+
+// DrawPattern fills the display with a test pattern.
+func (d *Device) DrawPattern(p Pattern) error {
+	sw, sh := d.Size()
+	w, h := int(sw), int(sh)
+	pixel := patternFunc(p, w, h)
+	if pixel == nil {
+		return errors.New("unknown test pattern")
+	}
+	err := d.blitRows(0, 0, sw, sh, func(row int, dst []byte) {
+		for x := 0; x < w; x++ {
+			put565(dst[x*2:], RGBATo565BGR(pixel(x, row)))
+		}
+	})
+	if err != nil || p != PatternEdges {
+		return err
+	}
+	// Which way is up, and where the origin is
+	white, black := color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255}
+	tw, _ := MeasureText("TOP", Font6x11)
+	if err := d.DrawText(int16((w-tw)/2), 4, "TOP", Font6x11, white, black); err != nil {
+		return err
+	}
+	return d.DrawText(4, 4, "0,0", Font6x11, white, black)
+}
+
+// patternFunc returns the color of each pixel of pattern p on a w x h
+// display, nil for an unknown pattern
+func patternFunc(p Pattern, w, h int) func(x, y int) color.RGBA {
+	ramp := func(v, n int) uint8 {
+		if n <= 1 {
+			return 0
+		}
+		return uint8(v * 255 / (n - 1))
+	}
+	switch p {
+	case PatternColorBars:
+		bars := []color.RGBA{
+			{255, 255, 255, 255}, {255, 255, 0, 255}, {0, 255, 255, 255}, {0, 255, 0, 255},
+			{255, 0, 255, 255}, {255, 0, 0, 255}, {0, 0, 255, 255}, {0, 0, 0, 255},
+		}
+		return func(x, y int) color.RGBA { return bars[x*len(bars)/w] }
+	case PatternGrid:
+		return func(x, y int) color.RGBA {
+			switch {
+			case x == 0 || y == 0 || x == w-1 || y == h-1 || x%50 == 0 || y%50 == 0:
+				return color.RGBA{255, 255, 255, 255}
+			case x%10 == 0 || y%10 == 0:
+				return color.RGBA{96, 96, 96, 255}
+			}
+			return color.RGBA{0, 0, 0, 255}
+		}
+	case PatternEdges:
+		return func(x, y int) color.RGBA {
+			switch {
+			case y == 0:
+				return edgeTop
+			case x == w-1:
+				return edgeRight
+			case y == h-1:
+				return edgeBottom
+			case x == 0:
+				return edgeLeft
+			}
+			return color.RGBA{0, 0, 0, 255}
+		}
+	case PatternGradient:
+		return func(x, y int) color.RGBA {
+			if y < h/2 {
+				v := ramp(x, w)
+				return color.RGBA{v, v, v, 255}
+			}
+			r := ramp(x, w)
+			return color.RGBA{255 - r, r, ramp(y-h/2, h-h/2), 255}
+		}
+	case PatternCheckerboard:
+		return func(x, y int) color.RGBA {
+			if (x/8+y/8)%2 == 0 {
+				return color.RGBA{255, 255, 255, 255}
+			}
+			return color.RGBA{0, 0, 0, 255}
+		}
+	case PatternRamps:
+		return func(x, y int) color.RGBA {
+			v := ramp(x, w)
+			switch y * 4 / h {
+			case 0:
+				return color.RGBA{v, 0, 0, 255}
+			case 1:
+				return color.RGBA{0, v, 0, 255}
+			case 2:
+				return color.RGBA{0, 0, v, 255}
+			}
+			return color.RGBA{v, v, v, 255}
+		}
+	}
+	return nil
+}
+
+// Size of the controller's frame memory, in the native portrait orientation
+const (
+	gramWidth  = 240
+	gramHeight = 320
+)
+
+// DrawOffsetMarkers helps find ColumnOffset and RowOffset for a new panel.
+// It clears the controller's whole frame memory, ignoring the configured
+// offsets, and for each candidate column offset draws a vertical line at
+// that memory column and one at the last column the panel would use with
+// it, labeled with the candidate. Row candidates get horizontal lines the
+// same way. The right offsets are the ones whose lines sit exactly on the
+// visible edges, with their labels inside.
+//
+// Coordinates follow the current rotation, so run it after SetRotation to
+// find the offsets of each orientation, then redraw with DrawPattern and
+// PatternEdges to check which side is up.
+func (d *Device) DrawOffsetMarkers(columns, rows []int16) error {
+	sw, sh := d.Size()
+	width, height, colOff, rowOff := d.width, d.height, d.columnOffset, d.rowOffset
+	defer func() {
+		d.width, d.height, d.columnOffset, d.rowOffset = width, height, colOff, rowOff
+		d.window.invalidate()
+	}()
+	// Address the whole memory without offsets
+	d.width, d.height, d.columnOffset, d.rowOffset = gramWidth, gramHeight, 0, 0
+	d.window.invalidate()
+	mw, mh := d.Size()
+	if err := d.FillRectangle(0, 0, mw, mh, color.RGBA{0, 0, 0, 255}); err != nil {
+		return err
+	}
+
+	colors := []color.RGBA{edgeTop, edgeRight, edgeBottom, edgeLeft, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255}}
+	// Labels are transparent so they don't hide the lines of close candidates
+	var transparent color.RGBA
+	for i, c := range columns {
+		fg := colors[i%len(colors)]
+		// Stagger the labels down the middle so they don't overlap
+		ly := mh/2 - int16(len(columns))*6 + int16(i)*12
+		label := strconv.Itoa(int(c))
+		lw, _ := MeasureText(label, Font6x11)
+		for _, x := range []int16{c, c + sw - 1} {
+			if x < 0 || x >= mw {
+				continue
+			}
+			if err := d.FillRectangle(x, 0, 1, mh, fg); err != nil {
+				return err
+			}
+		}
+		if err := d.DrawText(c+2, ly, label, Font6x11, fg, transparent); err != nil {
+			return err
+		}
+		if err := d.DrawText(c+sw-3-int16(lw), ly, label, Font6x11, fg, transparent); err != nil {
+			return err
+		}
+	}
+	for i, r := range rows {
+		fg := colors[i%len(colors)]
+		lx := mw/2 - int16(len(rows))*12 + int16(i)*24
+		label := strconv.Itoa(int(r))
+		for _, y := range []int16{r, r + sh - 1} {
+			if y < 0 || y >= mh {
+				continue
+			}
+			if err := d.FillRectangle(0, y, mw, 1, fg); err != nil {
+				return err
+			}
+		}
+		if err := d.DrawText(lx, r+2, label, Font6x11, fg, transparent); err != nil {
+			return err
+		}
+		if err := d.DrawText(lx, r+sh-13, label, Font6x11, fg, transparent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
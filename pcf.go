@@ -0,0 +1,227 @@
+package gc9307
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// PCF table types
+const (
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfBDFEncodings    = 1 << 5
+	pcfBDFAccelerators = 1 << 8
+)
+
+// PCF table format flags
+const (
+	pcfGlyphPadMask      = 3
+	pcfByteMask          = 1 << 2 // Most significant byte first
+	pcfBitMask           = 1 << 3 // Most significant bit first
+	pcfScanUnitMask      = 3 << 4
+	pcfCompressedMetrics = 0x100
+	pcfFormatMask        = 0xFFFFFF00
+)
+
+// PCFFont is a Font read from a PCF (Portable Compiled Format) file, the
+// binary form of BDF used by X11. Compressed .pcf.gz files can be read
+// through a gzip.Reader. As for BDFFont, encodings are taken as Unicode code
+// points and glyphs are decoded on first use.
+type PCFFont struct {
+	data    []byte
+	metrics pcfTable
+	bitmaps pcfTable
+	enc     pcfTable
+	ascent  int
+	descent int
+	cache   glyphCache
+}
+
+// pcfTable locates a table in the file
+type pcfTable struct {
+	format uint32
+	offset int
+	size   int
+}
+
+func (t pcfTable) order() binary.ByteOrder {
+	if t.format&pcfByteMask != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// ParsePCF reads a PCF font.
+func ParsePCF(r io.Reader) (*PCFFont, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 || string(data[:4]) != "\x01fcp" {
+		return nil, errors.New("pcf: not a PCF file")
+	}
+	f := &PCFFont{data: data}
+	f.cache.decode = f.decode
+
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	if count < 0 || 8+count*16 > len(data) {
+		return nil, errors.New("pcf: truncated table of contents")
+	}
+	tables := map[uint32]pcfTable{}
+	for i := 0; i < count; i++ {
+		e := data[8+i*16:]
+		t := pcfTable{
+			size:   int(binary.LittleEndian.Uint32(e[8:])),
+			offset: int(binary.LittleEndian.Uint32(e[12:])),
+		}
+		if t.offset < 0 || t.size < 4 || t.offset+t.size > len(data) {
+			return nil, errors.New("pcf: table outside of file")
+		}
+		// Entries are type, format, size and offset. The format stored in
+		// the table itself is authoritative.
+		t.format = binary.LittleEndian.Uint32(data[t.offset:])
+		tables[binary.LittleEndian.Uint32(e)] = t
+	}
+
+	var ok bool
+	if f.metrics, ok = tables[pcfMetrics]; !ok {
+		return nil, errors.New("pcf: missing metrics table")
+	}
+	if f.bitmaps, ok = tables[pcfBitmaps]; !ok {
+		return nil, errors.New("pcf: missing bitmaps table")
+	}
+	if f.enc, ok = tables[pcfBDFEncodings]; !ok || f.enc.size < 14 {
+		return nil, errors.New("pcf: missing encodings table")
+	}
+	accel, ok := tables[pcfBDFAccelerators]
+	if !ok {
+		accel, ok = tables[pcfAccelerators]
+	}
+	if !ok || accel.size < 20 {
+		return nil, errors.New("pcf: missing accelerators table")
+	}
+	// Eight flag bytes follow the format, then the font ascent and descent
+	o := accel.order()
+	f.ascent = int(int32(o.Uint32(data[accel.offset+12:])))
+	f.descent = int(int32(o.Uint32(data[accel.offset+16:])))
+	return f, nil
+}
+
+// Glyph implements Font.
+func (f *PCFFont) Glyph(r rune) (*Glyph, bool) {
+	return f.cache.get(r)
+}
+
+// Metrics implements Font.
+func (f *PCFFont) Metrics() (ascent, descent int) {
+	return f.ascent, f.descent
+}
+
+// index returns the glyph index of r from the encodings table
+func (f *PCFFont) index(r rune) (int, bool) {
+	t := f.enc
+	o := t.order()
+	p := f.data[t.offset+4 : t.offset+t.size]
+	minCol, maxCol := int(o.Uint16(p)), int(o.Uint16(p[2:]))
+	minRow, maxRow := int(o.Uint16(p[4:])), int(o.Uint16(p[6:]))
+	row, col := int(r>>8), int(r&0xFF)
+	if r > 0xFFFF || row < minRow || row > maxRow || col < minCol || col > maxCol {
+		return 0, false
+	}
+	i := 10 + 2*((row-minRow)*(maxCol-minCol+1)+col-minCol)
+	if i+2 > len(p) {
+		return 0, false
+	}
+	glyph := o.Uint16(p[i:])
+	return int(glyph), glyph != 0xFFFF
+}
+
+// decode builds the glyph of r, nil if the font does not have it
+func (f *PCFFont) decode(r rune) *Glyph {
+	i, ok := f.index(r)
+	if !ok {
+		return nil
+	}
+
+	// Metrics
+	t := f.metrics
+	o := t.order()
+	p := f.data[t.offset+4 : t.offset+t.size]
+	var lsb, rsb, width, ascent, descent int
+	if t.format&pcfFormatMask == pcfCompressedMetrics {
+		if i >= int(o.Uint16(p)) || 2+5*i+5 > len(p) {
+			return nil
+		}
+		m := p[2+5*i:]
+		v := func(j int) int { return int(m[j]) - 0x80 }
+		lsb, rsb, width, ascent, descent = v(0), v(1), v(2), v(3), v(4)
+	} else {
+		if i >= int(o.Uint32(p)) || 4+12*i+12 > len(p) {
+			return nil
+		}
+		m := p[4+12*i:]
+		v := func(j int) int { return int(int16(o.Uint16(m[2*j:]))) }
+		lsb, rsb, width, ascent, descent = v(0), v(1), v(2), v(3), v(4)
+	}
+	g := &Glyph{
+		Width:   rsb - lsb,
+		Height:  ascent + descent,
+		OffsetX: lsb,
+		OffsetY: -ascent,
+		Advance: width,
+	}
+	if g.Width < 0 || g.Height < 0 {
+		return nil
+	}
+	g.Stride = (g.Width + 7) / 8
+	g.Bits = make([]byte, g.Stride*g.Height)
+
+	// Bitmap
+	t = f.bitmaps
+	o = t.order()
+	p = f.data[t.offset+4 : t.offset+t.size]
+	count := int(o.Uint32(p))
+	if i >= count || len(p) < 4+4*count+16 {
+		return nil
+	}
+	pad := 1 << (t.format & pcfGlyphPadMask)
+	unit := 1 << ((t.format & pcfScanUnitMask) >> 4)
+	msbFirst := t.format&pcfBitMask != 0
+	// Bytes are swapped within scan units when byte and bit order differ
+	swap := unit > 1 && (t.format&pcfByteMask != 0) != msbFirst
+	// Rows are padded to the glyph pad. Scan units are counted from the
+	// start of the bitmap data, not of the glyph or row, as X swaps the
+	// whole block at once
+	data := p[4+4*count+16:]
+	start := int(o.Uint32(p[4+4*i:]))
+	srcStride := (g.Stride + pad - 1) / pad * pad
+	if start < 0 || start+srcStride*g.Height > len(data) {
+		return nil
+	}
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Stride; x++ {
+			j := start + y*srcStride + x
+			if swap {
+				j = j - j%unit + unit - 1 - j%unit
+				if j >= len(data) {
+					return nil
+				}
+			}
+			b := data[j]
+			if !msbFirst {
+				b = reverseBits(b)
+			}
+			g.Bits[y*g.Stride+x] = b
+		}
+	}
+	return g
+}
+
+// reverseBits mirrors the bit order of b
+func reverseBits(b uint8) uint8 {
+	b = b>>4 | b<<4
+	b = (b&0xCC)>>2 | (b&0x33)<<2
+	return (b&0xAA)>>1 | (b&0x55)<<1
+}
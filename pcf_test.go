@@ -0,0 +1,118 @@
+package gc9307
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// pcfFile builds a little-endian PCF font with the single 3x2 glyph 'A',
+// whose bitmap is at offset in data, stored in the given bitmaps table
+// format.
+func pcfFile(bitmapFormat, offset uint32, data []byte) []byte {
+	le := binary.LittleEndian
+	var accel, metrics, bitmaps, enc []byte
+	accel = le.AppendUint32(accel, 0)
+	accel = append(accel, make([]byte, 8)...)
+	accel = le.AppendUint32(accel, 2) // Ascent
+	accel = le.AppendUint32(accel, 0) // Descent
+
+	metrics = le.AppendUint32(metrics, pcfCompressedMetrics)
+	metrics = le.AppendUint16(metrics, 1)
+	metrics = append(metrics, 0x80, 0x83, 0x84, 0x82, 0x80) // lsb 0, rsb 3, width 4, ascent 2, descent 0
+
+	bitmaps = le.AppendUint32(bitmaps, bitmapFormat)
+	bitmaps = le.AppendUint32(bitmaps, 1)
+	bitmaps = le.AppendUint32(bitmaps, offset)
+	bitmaps = append(bitmaps, make([]byte, 16)...)
+	bitmaps = append(bitmaps, data...)
+
+	enc = le.AppendUint32(enc, 0)
+	for _, v := range []uint16{'A', 'A', 0, 0, 0, 0} { // Columns, rows, default char, index
+		enc = le.AppendUint16(enc, v)
+	}
+
+	tables := []struct {
+		typ  uint32
+		data []byte
+	}{{pcfAccelerators, accel}, {pcfMetrics, metrics}, {pcfBitmaps, bitmaps}, {pcfBDFEncodings, enc}}
+	f := []byte("\x01fcp")
+	f = le.AppendUint32(f, uint32(len(tables)))
+	at := len(f) + 16*len(tables)
+	for _, t := range tables {
+		f = le.AppendUint32(f, t.typ)
+		f = le.AppendUint32(f, le.Uint32(t.data))
+		f = le.AppendUint32(f, uint32(len(t.data)))
+		f = le.AppendUint32(f, uint32(at))
+		at += len(t.data)
+	}
+	for _, t := range tables {
+		f = append(f, t.data...)
+	}
+	return f
+}
+
+func TestPCFBitmapFormats(t *testing.T) {
+	const (
+		pad1  = 0
+		pad4  = 2
+		unit1 = 0
+		unit4 = 2 << 4
+		msbit = pcfBitMask
+	)
+	for _, tc := range []struct {
+		name   string
+		format uint32
+		offset uint32
+		data   []byte
+		want   []byte // Glyph bits, nil for no glyph
+	}{
+		{"Pad1", msbit | pad1 | unit1, 0, []byte{0xA0, 0x40}, []byte{0xA0, 0x40}},
+		{"LSBitFirst", pad1 | unit1, 0, []byte{0x05, 0x02}, []byte{0xA0, 0x40}},
+		{"Pad4", msbit | pad4 | unit1, 0, []byte{0xA0, 0, 0, 0, 0x40, 0, 0, 0}, []byte{0xA0, 0x40}},
+		// Byte order differs from bit order: bytes are swapped in 4-byte
+		// units counted from the start of the bitmap data
+		{"Pad4Unit4", msbit | pad4 | unit4, 0, []byte{0, 0, 0, 0xA0, 0, 0, 0, 0x40}, []byte{0xA0, 0x40}},
+		{"Pad1Unit4", msbit | pad1 | unit4, 0, []byte{0, 0, 0x40, 0xA0}, []byte{0xA0, 0x40}},
+		{"Pad1Unit4Offset", msbit | pad1 | unit4, 2, []byte{0x40, 0xA0, 0, 0, 0, 0, 0, 0}, []byte{0xA0, 0x40}},
+		{"Truncated", msbit | pad4 | unit1, 0, []byte{0xA0, 0, 0, 0, 0x40}, nil},
+		{"TruncatedUnit", msbit | pad1 | unit4, 0, []byte{0, 0}, nil},
+		{"OffsetPastEnd", msbit | pad1 | unit1, 100, []byte{0xA0, 0x40}, nil},
+	} {
+		f, err := ParsePCF(bytes.NewReader(pcfFile(tc.format, tc.offset, tc.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, ok := f.Glyph('A')
+		if tc.want == nil {
+			if ok {
+				t.Errorf("%s: got a glyph from a truncated bitmap", tc.name)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: no glyph", tc.name)
+			continue
+		}
+		if !bytes.Equal(g.Bits, tc.want) {
+			t.Errorf("%s: bits %#x, want %#x", tc.name, g.Bits, tc.want)
+		}
+	}
+}
+
+func TestParsePCFErrors(t *testing.T) {
+	valid := pcfFile(pcfBitMask, 0, []byte{0xA0, 0x40})
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"Magic", []byte("\x01fcq\x00\x00\x00\x00")},
+		{"TruncatedTOC", valid[:20]},
+		{"TableOutside", valid[:len(valid)-4]},
+	} {
+		if _, err := ParsePCF(bytes.NewReader(tc.data)); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package gc9307
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// QOI, the Quite OK Image format (https://qoiformat.org), is lossless like
+// PNG but decodes several times faster, which matters on small boards.
+// Importing this package registers it with image.Decode.
+func init() {
+	image.RegisterFormat("qoi", "qoif", DecodeQOI, DecodeQOIConfig)
+}
+
+// Largest image DecodeQOI accepts, as in the reference implementation
+const qoiMaxPixels = 400000000
+
+// DecodeQOIConfig returns the size of a QOI image without decoding it.
+func DecodeQOIConfig(r io.Reader) (image.Config, error) {
+	w, h, err := readQOIHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: w, Height: h}, nil
+}
+
+// DecodeQOI reads a QOI image as an *image.NRGBA.
+func DecodeQOI(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	w, h, err := readQOIHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	var index [64][4]byte
+	px := [4]byte{0, 0, 0, 0xFF}
+	run := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if run > 0 {
+			run--
+		} else {
+			b1, err := br.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			switch {
+			case b1 == 0xFE: // QOI_OP_RGB
+				if _, err := io.ReadFull(br, px[:3]); err != nil {
+					return nil, unexpectedEOF(err)
+				}
+			case b1 == 0xFF: // QOI_OP_RGBA
+				if _, err := io.ReadFull(br, px[:]); err != nil {
+					return nil, unexpectedEOF(err)
+				}
+			case b1>>6 == 0: // QOI_OP_INDEX
+				px = index[b1]
+			case b1>>6 == 1: // QOI_OP_DIFF
+				px[0] += b1>>4&3 - 2
+				px[1] += b1>>2&3 - 2
+				px[2] += b1&3 - 2
+			case b1>>6 == 2: // QOI_OP_LUMA
+				b2, err := br.ReadByte()
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				dg := b1&0x3F - 32
+				px[0] += dg - 8 + b2>>4
+				px[1] += dg
+				px[2] += dg - 8 + b2&0x0F
+			default: // QOI_OP_RUN
+				run = int(b1 & 0x3F)
+			}
+			index[(int(px[0])*3+int(px[1])*5+int(px[2])*7+int(px[3])*11)%64] = px
+		}
+		copy(img.Pix[i:], px[:])
+	}
+	return img, nil
+}
+
+// readQOIHeader reads the 14 byte header and returns the image size
+func readQOIHeader(r io.Reader) (w, h int, err error) {
+	var hdr [14]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	if string(hdr[:4]) != "qoif" {
+		return 0, 0, errors.New("qoi: invalid magic")
+	}
+	w = int(binary.BigEndian.Uint32(hdr[4:]))
+	h = int(binary.BigEndian.Uint32(hdr[8:]))
+	if w <= 0 || h <= 0 || int64(w)*int64(h) > qoiMaxPixels {
+		return 0, 0, errors.New("qoi: invalid image size")
+	}
+	if c := hdr[12]; c != 3 && c != 4 {
+		return 0, 0, errors.New("qoi: invalid channel count")
+	}
+	return w, h, nil
+}
+
+// unexpectedEOF turns io.EOF in the middle of an image into io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package gc9307
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// qoiFile builds a QOI file of w x h pixels from the encoded chunks.
+func qoiFile(w, h uint32, channels byte, chunks ...byte) []byte {
+	f := []byte("qoif")
+	f = binary.BigEndian.AppendUint32(f, w)
+	f = binary.BigEndian.AppendUint32(f, h)
+	f = append(f, channels, 0)
+	f = append(f, chunks...)
+	return append(f, 0, 0, 0, 0, 0, 0, 0, 1)
+}
+
+// Every chunk type once, 7 pixels
+var qoiChunks = []byte{
+	0xFE, 10, 20, 30, // RGB
+	0x76,       // DIFF, red +1, green -1
+	0xA4, 0x6B, // LUMA, green +4, red +2, blue +7
+	0x09,             // INDEX of the first pixel
+	0xC1,             // RUN of 2
+	0xFF, 1, 2, 3, 4, // RGBA
+}
+
+func TestDecodeQOI(t *testing.T) {
+	img, format, err := image.Decode(bytes.NewReader(qoiFile(7, 1, 4, qoiChunks...)))
+	if err != nil || format != "qoi" {
+		t.Fatalf("image.Decode() = %q, %v", format, err)
+	}
+	want := []color.NRGBA{
+		{10, 20, 30, 255},
+		{11, 19, 30, 255},
+		{13, 23, 37, 255},
+		{10, 20, 30, 255},
+		{10, 20, 30, 255},
+		{10, 20, 30, 255},
+		{1, 2, 3, 4},
+	}
+	for x, c := range want {
+		if got := img.At(x, 0); got != c {
+			t.Errorf("pixel %d = %v, want %v", x, got, c)
+		}
+	}
+
+	cfg, err := DecodeQOIConfig(bytes.NewReader(qoiFile(7, 1, 4, qoiChunks...)))
+	if err != nil || cfg.Width != 7 || cfg.Height != 1 {
+		t.Errorf("DecodeQOIConfig() = %+v, %v", cfg, err)
+	}
+}
+
+func TestDecodeQOIErrors(t *testing.T) {
+	valid := qoiFile(7, 1, 4, qoiChunks...)
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want error // nil for any error
+	}{
+		{"Empty", nil, io.ErrUnexpectedEOF},
+		{"TruncatedHeader", valid[:10], io.ErrUnexpectedEOF},
+		{"Magic", append([]byte("qoig"), valid[4:]...), nil},
+		{"ZeroWidth", qoiFile(0, 1, 4), nil},
+		{"TooLarge", qoiFile(1<<16, 1<<16, 4), nil},
+		{"Channels", qoiFile(7, 1, 2, qoiChunks...), nil},
+		{"NoPixels", valid[:14], io.ErrUnexpectedEOF},
+		{"TruncatedRGB", valid[:16], io.ErrUnexpectedEOF},
+		{"TruncatedLUMA", valid[:20], io.ErrUnexpectedEOF},
+		{"TruncatedRGBA", valid[:25], io.ErrUnexpectedEOF},
+		// The run covers the last pixels, more are missing
+		{"ShortRun", qoiFile(8, 1, 4, qoiChunks...)[:14+len(qoiChunks)], io.ErrUnexpectedEOF},
+	} {
+		_, err := DecodeQOI(bytes.NewReader(tc.data))
+		if err == nil || tc.want != nil && !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
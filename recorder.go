@@ -0,0 +1,110 @@
+package gc9307
+
+import "fmt"
+
+// OpKind identifies a Transport operation recorded by Recorder.
+type OpKind uint8
+
+// Operations recorded by Recorder
+const (
+	OpCommand OpKind = iota // WriteCommand
+	OpData                  // WriteData
+	OpPixels                // WritePixels
+	OpRead                  // Read
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpCommand:
+		return "Command"
+	case OpData:
+		return "Data"
+	case OpPixels:
+		return "Pixels"
+	case OpRead:
+		return "Read"
+	}
+	return fmt.Sprintf("OpKind(%d)", uint8(k))
+}
+
+// Op is one recorded Transport operation.
+type Op struct {
+	Kind  OpKind
+	Cmd   uint8  // Command byte for OpCommand and OpRead
+	Data  []byte // Parameters, data, pixels or bytes returned by a read
+	Count int    // Number of copies of Data for OpPixels
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case OpCommand:
+		return fmt.Sprintf("Command 0x%02X % X", o.Cmd, o.Data)
+	case OpPixels:
+		return fmt.Sprintf("Pixels %d bytes x%d", len(o.Data), o.Count)
+	case OpRead:
+		return fmt.Sprintf("Read 0x%02X -> % X", o.Cmd, o.Data)
+	}
+	return fmt.Sprintf("%s % X", o.Kind, o.Data)
+}
+
+// Recorder is a Transport that records every operation instead of talking to
+// a panel, to test drawing code without hardware.
+type Recorder struct {
+	Ops []Op
+	// Responses holds the bytes returned by Read for each command. Missing or
+	// short responses read as zeros.
+	Responses map[uint8][]byte
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{Responses: map[uint8][]byte{}}
+}
+
+// Reset forgets the recorded operations.
+func (r *Recorder) Reset() {
+	r.Ops = r.Ops[:0]
+}
+
+// WriteCommand implements Transport.
+func (r *Recorder) WriteCommand(cmd uint8, params []byte) error {
+	r.Ops = append(r.Ops, Op{Kind: OpCommand, Cmd: cmd, Data: clone(params)})
+	return nil
+}
+
+// WriteData implements Transport.
+func (r *Recorder) WriteData(data []byte) error {
+	r.Ops = append(r.Ops, Op{Kind: OpData, Data: clone(data)})
+	return nil
+}
+
+// WritePixels implements Transport.
+func (r *Recorder) WritePixels(pix []byte, count int) error {
+	r.Ops = append(r.Ops, Op{Kind: OpPixels, Data: clone(pix), Count: count})
+	return nil
+}
+
+// Read implements Transport.
+func (r *Recorder) Read(cmd uint8, data []byte) error {
+	for i := range data {
+		data[i] = 0
+	}
+	copy(data, r.Responses[cmd])
+	r.Ops = append(r.Ops, Op{Kind: OpRead, Cmd: cmd, Data: clone(data)})
+	return nil
+}
+
+// Flush implements Transport.
+func (r *Recorder) Flush() error {
+	return nil
+}
+
+// clone returns a copy of b, nil when b is empty
+func clone(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+var _ Transport = &Recorder{}
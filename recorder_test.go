@@ -0,0 +1,203 @@
+package gc9307
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+// checkOps compares the recorded operations with want, one Op.String each.
+func checkOps(t *testing.T, r *Recorder, want []string) {
+	t.Helper()
+	got := make([]string, len(r.Ops))
+	for i, op := range r.Ops {
+		got[i] = strings.TrimSpace(op.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n\t%s\nwant:\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+	r.Reset()
+}
+
+// The Photonicat 2 panel, upside down
+var pcatConfig = Config{Width: 172, Height: 320, Rotation: ROTATION_180, ColumnOffset: 34}
+
+// recorderDevice returns a 172x320 device recording into a Recorder,
+// configured without a reset or a clear.
+func recorderDevice(tb testing.TB) (*Device, *Recorder) {
+	r := NewRecorder()
+	d := NewWithTransport(r, gpio.INVALID, gpio.INVALID)
+	cfg := pcatConfig
+	cfg.KeepContents = true
+	configureTest(tb, &d, cfg, true)
+	r.Reset()
+	return &d, r
+}
+
+// sentPixels returns the pixel data of all recorded WritePixels calls.
+func sentPixels(r *Recorder) []byte {
+	var pix []byte
+	for _, op := range r.Ops {
+		if op.Kind == OpPixels {
+			for i := 0; i < op.Count; i++ {
+				pix = append(pix, op.Data...)
+			}
+		}
+	}
+	return pix
+}
+
+func TestConfigure(t *testing.T) {
+	window := []string{
+		"Command 0x36",
+		"Data 40",
+		"Command 0x2A 00 22 00 CD",
+		"Command 0x2B 00 00 01 3F",
+		"Command 0x2C",
+	}
+	on := []string{
+		"Command 0x20",
+		"Command 0x13",
+		"Command 0x29",
+	}
+	for _, tc := range []struct {
+		name        string
+		initialized bool
+		keep        bool
+		want        [][]string
+	}{
+		{
+			name: "Reset",
+			want: [][]string{
+				{"Command 0x01", "Command 0x11", "Command 0x3A", "Data 55"},
+				window,
+				// The clear reuses the window just set
+				{"Command 0x2C", "Pixels 640 bytes x172"},
+				on,
+			},
+		},
+		{
+			name:        "Initialized",
+			initialized: true,
+			want:        [][]string{window, {"Command 0x2C", "Pixels 640 bytes x172"}, on},
+		},
+		{
+			name:        "KeepContents",
+			initialized: true,
+			keep:        true,
+			want:        [][]string{window, on},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRecorder()
+			d := NewWithTransport(r, gpio.INVALID, gpio.INVALID)
+			cfg := pcatConfig
+			cfg.KeepContents = tc.keep
+			configureTest(t, &d, cfg, tc.initialized)
+			var want []string
+			for _, w := range tc.want {
+				want = append(want, w...)
+			}
+			checkOps(t, r, want)
+		})
+	}
+}
+
+func TestSetWindowRotation(t *testing.T) {
+	for _, tc := range []struct {
+		rotation Rotation
+		want     []string
+	}{
+		{NO_ROTATION, []string{"Command 0x2A 00 24 00 2B", "Command 0x2B 00 07 00 0E"}},
+		{ROTATION_90, []string{"Command 0x2A 00 07 00 0E", "Command 0x2B 00 24 00 2B"}},
+		{ROTATION_180, []string{"Command 0x2A 00 24 00 2B", "Command 0x2B 00 02 00 09"}},
+		{ROTATION_270, []string{"Command 0x2A 00 02 00 09", "Command 0x2B 00 02 00 09"}},
+	} {
+		r := NewRecorder()
+		d := NewWithTransport(r, gpio.INVALID, gpio.INVALID)
+		cfg := pcatConfig
+		cfg.RowOffset = 5
+		cfg.KeepContents = true
+		configureTest(t, &d, cfg, true)
+		d.SetRotation(tc.rotation)
+		r.Reset()
+		d.setWindow(2, 2, 8, 8)
+		checkOps(t, r, append(tc.want, "Command 0x2C"))
+	}
+}
+
+func TestWindowCache(t *testing.T) {
+	r := NewRecorder()
+	d := NewWithTransport(r, gpio.INVALID, gpio.INVALID)
+	cfg := pcatConfig
+	cfg.KeepContents = true
+	configureTest(t, &d, cfg, true)
+	d.ResetWindowStats()
+	r.Reset()
+
+	red := color.RGBA{255, 0, 0, 255}
+	steps := []struct {
+		name string
+		draw func()
+		want []string
+	}{
+		{"new columns and rows", func() { d.FillRectangle(0, 0, 8, 8, red) }, []string{
+			"Command 0x2A 00 22 00 29",
+			"Command 0x2B 00 00 00 07",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"same columns", func() { d.FillRectangle(0, 8, 8, 8, red) }, []string{
+			"Command 0x2B 00 08 00 0F",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"same rows", func() { d.FillRectangle(8, 8, 8, 8, red) }, []string{
+			"Command 0x2A 00 2A 00 31",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"other window and back", func() { d.SetPixel(8, 8, red); d.FillRectangle(8, 8, 8, 8, red) }, []string{
+			"Command 0x2A 00 2A 00 2A",
+			"Command 0x2B 00 08 00 08",
+			"Command 0x2C",
+			"Pixels 2 bytes x1",
+			"Command 0x2A 00 2A 00 31",
+			"Command 0x2B 00 08 00 0F",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"unchanged", func() { d.FillRectangle(8, 8, 8, 8, red) }, []string{
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"raw command", func() { d.Command(NOP); d.FillRectangle(8, 8, 8, 8, red) }, []string{
+			"Command 0x00",
+			"Command 0x2A 00 2A 00 31",
+			"Command 0x2B 00 08 00 0F",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+		{"rotation", func() { d.SetRotation(ROTATION_180); d.FillRectangle(8, 8, 8, 8, red) }, []string{
+			"Command 0x36",
+			"Data 40",
+			"Command 0x2A 00 2A 00 31",
+			"Command 0x2B 00 08 00 0F",
+			"Command 0x2C",
+			"Pixels 128 bytes x1",
+		}},
+	}
+	for _, s := range steps {
+		t.Run(s.name, func(t *testing.T) {
+			s.draw()
+			checkOps(t, r, s.want)
+		})
+	}
+
+	want := WindowStats{ColumnSent: 6, ColumnSkipped: 2, RowSent: 6, RowSkipped: 2, MemoryWrites: 8}
+	if got := d.WindowStats(); got != want {
+		t.Errorf("WindowStats() = %+v, want %+v", got, want)
+	}
+}
@@ -6,6 +6,9 @@ const (
 	SWRESET    = 0x01
 	RDDID      = 0x04
 	RDDST      = 0x09
+	RDDPM      = 0x0A
+	RDDMADCTL  = 0x0B
+	RDDCOLMOD  = 0x0C
 	SLPIN      = 0x10
 	SLPOUT     = 0x11
 	PTLON      = 0x12
@@ -0,0 +1,180 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+)
+
+// RLEImage is a run-length compressed RGB565 image. It suits screens made of
+// large flat areas: embedded in the binary it takes a fraction of the plain
+// pixels, and DrawRLE expands the runs straight into the transfer batches,
+// without a full size buffer.
+//
+// Data is a sequence of packets, each starting with a byte n: if its top bit
+// is set, the next pixel is repeated (n&0x7F)+1 times, otherwise n+1 literal
+// pixels follow. Pixels are big endian RGB565 as sent to the panel, see
+// RGBATo565BGR, and packets may span rows.
+type RLEImage struct {
+	Width, Height int
+	Data          []byte
+}
+
+// NewRLEImage converts and compresses img.
+func NewRLEImage(img image.Image) *RLEImage {
+	b := img.Bounds()
+	pix := make([]byte, b.Dx()*b.Dy()*2)
+	for y := 0; y < b.Dy(); y++ {
+		imageRow565(img, 0, y, b.Dx(), pix[y*b.Dx()*2:])
+	}
+	return &RLEImage{Width: b.Dx(), Height: b.Dy(), Data: encodeRLE565(pix)}
+}
+
+// ParseRLEImage wraps compressed data, e.g. embedded with go:embed or written
+// by "gc9307-asset -rle -raw", after checking that it holds exactly width x
+// height pixels. The data is used in place, not copied.
+func ParseRLEImage(width, height int, data []byte) (*RLEImage, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("rle: invalid image size")
+	}
+	if !validRLE565(data, width*height) {
+		return nil, errors.New("rle: data does not match the image size")
+	}
+	return &RLEImage{Width: width, Height: height, Data: data}, nil
+}
+
+// Bounds returns the size of the image.
+func (m *RLEImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, m.Width, m.Height)
+}
+
+// DrawRLE draws m with its top left corner at (x, y), clipped to the display.
+// Runs are expanded directly into the transfer buffer while it is filled, so
+// memory use does not depend on the image size.
+func (d *Device) DrawRLE(x, y int16, m *RLEImage) error {
+	vis := image.Rect(int(x), int(y), int(x)+m.Width, int(y)+m.Height).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+	left := vis.Min.X - int(x)
+	src := rleRows(m.Data, m.Width, vis.Min.Y-int(y))
+	return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+		src(left, dst)
+	})
+}
+
+// rleRows returns a function expanding the rows of width pixels wide
+// compressed data in order, from row y. Each call expands the next row, from
+// column x on, into dst, whose length sets how many pixels are expanded.
+func rleRows(data []byte, width, y int) func(x int, dst []byte) {
+	r := rle565Reader{data: data}
+	r.skip(y * width)
+	return func(x int, dst []byte) {
+		r.skip(x)
+		r.read(dst)
+		r.skip(width - x - len(dst)/2)
+	}
+}
+
+// encodeRLE565 compresses big endian RGB565 pixels into packets, see RLEImage
+func encodeRLE565(pix []byte) []byte {
+	var out []byte
+	n := len(pix) / 2
+	same := func(i, j int) bool {
+		return pix[i*2] == pix[j*2] && pix[i*2+1] == pix[j*2+1]
+	}
+	for i := 0; i < n; {
+		run := 1
+		for i+run < n && run < 128 && same(i, i+run) {
+			run++
+		}
+		if run > 1 {
+			out = append(out, 0x80|byte(run-1), pix[i*2], pix[i*2+1])
+			i += run
+			continue
+		}
+		// Literal up to the next repeated pair
+		lit := 1
+		for i+lit < n && lit < 128 && !(i+lit+1 < n && same(i+lit, i+lit+1)) {
+			lit++
+		}
+		out = append(out, byte(lit-1))
+		out = append(out, pix[i*2:(i+lit)*2]...)
+		i += lit
+	}
+	return out
+}
+
+// validRLE565 reports whether data holds exactly n pixels of whole packets
+func validRLE565(data []byte, n int) bool {
+	for len(data) > 0 {
+		h := data[0]
+		k := int(h&0x7F) + 1
+		size := 3
+		if h&0x80 == 0 {
+			size = 1 + k*2
+		}
+		if len(data) < size || k > n {
+			return false
+		}
+		data, n = data[size:], n-k
+	}
+	return n == 0
+}
+
+// rle565Reader expands compressed RGB565 pixels sequentially. The data must
+// have been checked with validRLE565.
+type rle565Reader struct {
+	data   []byte
+	left   int  // Pixels remaining in the current packet
+	repeat bool // Whether the current packet is a run of data[0:2]
+}
+
+// next moves to the next packet once the current one is used up
+func (r *rle565Reader) next() {
+	if r.left > 0 {
+		return
+	}
+	if r.repeat {
+		r.data = r.data[2:]
+	}
+	h := r.data[0]
+	r.data = r.data[1:]
+	r.left, r.repeat = int(h&0x7F)+1, h&0x80 != 0
+}
+
+// read expands len(dst)/2 pixels into dst
+func (r *rle565Reader) read(dst []byte) {
+	for len(dst) > 0 {
+		r.next()
+		k := len(dst) / 2
+		if k > r.left {
+			k = r.left
+		}
+		if r.repeat {
+			for i := 0; i < k; i++ {
+				dst[i*2], dst[i*2+1] = r.data[0], r.data[1]
+			}
+		} else {
+			copy(dst, r.data[:k*2])
+			r.data = r.data[k*2:]
+		}
+		dst = dst[k*2:]
+		r.left -= k
+	}
+}
+
+// skip moves past n pixels
+func (r *rle565Reader) skip(n int) {
+	for n > 0 {
+		r.next()
+		k := n
+		if k > r.left {
+			k = r.left
+		}
+		if !r.repeat {
+			r.data = r.data[k*2:]
+		}
+		n -= k
+		r.left -= k
+	}
+}
@@ -0,0 +1,112 @@
+package gc9307
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// rlePixels returns n big endian RGB565 pixels, the i-th being v(i).
+func rlePixels(n int, v func(i int) uint16) []byte {
+	pix := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		put565(pix[i*2:], v(i))
+	}
+	return pix
+}
+
+func TestEncodeRLE565(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		pix  []byte
+		size int // Length of the packets, 0 to skip the check
+	}{
+		{"Single", rlePixels(1, func(i int) uint16 { return 7 }), 3},
+		{"Run", rlePixels(128, func(i int) uint16 { return 7 }), 3},
+		// Runs are split at 128 pixels
+		{"LongRun", rlePixels(300, func(i int) uint16 { return 7 }), 9},
+		{"Literal", rlePixels(128, func(i int) uint16 { return uint16(i) }), 1 + 256},
+		{"LongLiteral", rlePixels(129, func(i int) uint16 { return uint16(i) }), 2 + 258},
+		// Literal, run of 3, literal
+		{"Mixed", rlePixels(7, func(i int) uint16 { return []uint16{1, 2, 3, 3, 3, 4, 5}[i] }), 5 + 3 + 5},
+		{"Stripes", rlePixels(1000, func(i int) uint16 { return uint16(i / 5 % 3) }), 0},
+	} {
+		data := encodeRLE565(tc.pix)
+		n := len(tc.pix) / 2
+		if tc.size != 0 && len(data) != tc.size {
+			t.Errorf("%s: %d bytes of packets, want %d", tc.name, len(data), tc.size)
+		}
+		if !validRLE565(data, n) {
+			t.Errorf("%s: packets do not hold %d pixels", tc.name, n)
+			continue
+		}
+		r := rle565Reader{data: data}
+		got := make([]byte, len(tc.pix))
+		// Uneven chunks, so that reads end inside packets
+		for i := 0; i < len(got); i += 6 {
+			end := i + 6
+			if end > len(got) {
+				end = len(got)
+			}
+			r.read(got[i:end])
+		}
+		if !bytes.Equal(got, tc.pix) {
+			t.Errorf("%s: expanded pixels differ", tc.name)
+		}
+	}
+}
+
+func TestParseRLEImage(t *testing.T) {
+	valid := encodeRLE565(rlePixels(6, func(i int) uint16 { return []uint16{1, 2, 3, 3, 3, 3}[i] }))
+	for _, tc := range []struct {
+		name          string
+		width, height int
+		data          []byte
+		ok            bool
+	}{
+		{"Valid", 3, 2, valid, true},
+		{"OtherShape", 2, 3, valid, true},
+		{"ZeroWidth", 0, 2, valid, false},
+		{"NegativeHeight", 3, -2, valid, false},
+		{"TooFewPixels", 4, 2, valid, false},
+		{"TooManyPixels", 5, 1, valid, false},
+		{"Empty", 3, 2, nil, false},
+		{"TruncatedLiteral", 3, 2, valid[:3], false},
+		{"TruncatedRun", 3, 2, valid[:len(valid)-1], false},
+		{"TrailingByte", 3, 2, append(append([]byte{}, valid...), 0), false},
+	} {
+		_, err := ParseRLEImage(tc.width, tc.height, tc.data)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: error %v, want ok %t", tc.name, err, tc.ok)
+		}
+	}
+}
+
+// DrawRLE skips the packets of the rows and columns off the display.
+func TestDrawRLEClipped(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			// Runs across rows on the left, literals on the right
+			img.Set(x, y, colors[(x/2*(x+y))%4])
+		}
+	}
+	m := NewRLEImage(img)
+
+	d, r := recorderDevice(t)
+	if err := d.DrawRLE(-1, -1, m); err != nil {
+		t.Fatal(err)
+	}
+	var want []byte
+	for y := 1; y < 3; y++ {
+		for x := 1; x < 4; x++ {
+			want = append(want, 0, 0)
+			put565(want[len(want)-2:], RGBATo565BGR(img.RGBAAt(x, y)))
+		}
+	}
+	if got := sentPixels(r); !bytes.Equal(got, want) {
+		t.Errorf("sent %x, want %x", got, want)
+	}
+}
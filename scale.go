@@ -0,0 +1,213 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ScaleMode selects how DrawImageFit sizes an image to its rectangle.
+type ScaleMode uint8
+
+// Scale modes
+const (
+	ScaleFit     ScaleMode = iota // Largest size showing the whole image, keeping the aspect ratio
+	ScaleFill                     // Smallest size covering the rectangle, keeping the aspect ratio; the excess is cropped
+	ScaleStretch                  // Exactly the rectangle, ignoring the aspect ratio
+	ScaleCenter                   // Native size, centered and cropped
+)
+
+// Filter selects how DrawImageFit resamples a scaled image.
+type Filter uint8
+
+// Resampling filters
+const (
+	NearestNeighbor Filter = iota // Fastest, blocky
+	Bilinear                      // Smooth when enlarging, aliases when shrinking a lot
+	BoxFilter                     // Averages all covered pixels, best for shrinking
+)
+
+// DrawImageFit draws img scaled into r according to mode, clipped to r and to
+// the display. With ScaleFit and ScaleCenter the parts of r not covered by
+// the image are left untouched.
+//
+// The image is resampled one display row at a time, reading only the source
+// rows that row needs, so no scaled or converted copy of the whole image is
+// made. An image that needs no scaling is converted straight to RGB565.
+func (d *Device) DrawImageFit(r image.Rectangle, img image.Image, mode ScaleMode, filter Filter) error {
+	src := img.Bounds()
+	if src.Empty() || r.Empty() {
+		return errors.New("empty image or rectangle")
+	}
+
+	// dst is where the whole image lands, possibly larger than r
+	w, h := r.Dx(), r.Dy()
+	sx := float64(w) / float64(src.Dx())
+	sy := float64(h) / float64(src.Dy())
+	switch mode {
+	case ScaleFit:
+		s := math.Min(sx, sy)
+		w, h = int(float64(src.Dx())*s+0.5), int(float64(src.Dy())*s+0.5)
+	case ScaleFill:
+		s := math.Max(sx, sy)
+		w, h = int(float64(src.Dx())*s+0.5), int(float64(src.Dy())*s+0.5)
+	case ScaleCenter:
+		w, h = src.Dx(), src.Dy()
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.Rect(0, 0, w, h).Add(r.Min).Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+	vis := dst.Intersect(r).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+
+	if dst.Size() == src.Size() {
+		// Nothing to resample, convert straight to RGB565
+		return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, out []byte) {
+			imageRow565(img, vis.Min.X-dst.Min.X, vis.Min.Y-dst.Min.Y+row, vis.Dx(), out)
+		})
+	}
+
+	xs := newAxisMap(filter, vis.Min.X-dst.Min.X, vis.Dx(), w, src.Dx())
+	ys := newAxisMap(filter, vis.Min.Y-dst.Min.Y, vis.Dy(), h, src.Dy())
+	rows := rowCache{img: img, rows: map[int][]color.RGBA{}}
+	return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, out []byte) {
+		y0, y1 := ys.lo[row], ys.hi[row]
+		rows.drop(y0)
+		for i := range xs.lo {
+			x0, x1 := xs.lo[i], xs.hi[i]
+			var c color.RGBA
+			switch filter {
+			case Bilinear:
+				top := lerpRGBA(rows.get(y0)[x0], rows.get(y0)[x1], xs.w[i])
+				bottom := lerpRGBA(rows.get(y1)[x0], rows.get(y1)[x1], xs.w[i])
+				c = lerpRGBA(top, bottom, ys.w[row])
+			case BoxFilter:
+				var r, g, b, a, n uint32
+				for y := y0; y <= y1; y++ {
+					line := rows.get(y)
+					for x := x0; x <= x1; x++ {
+						p := line[x]
+						r, g, b, a = r+uint32(p.R), g+uint32(p.G), b+uint32(p.B), a+uint32(p.A)
+						n++
+					}
+				}
+				c = color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)}
+			default:
+				c = rows.get(y0)[x0]
+			}
+			put565(out[i*2:], RGBATo565BGR(c))
+		}
+	})
+}
+
+// axisMap holds, for each output pixel along one axis, the source pixels it
+// is computed from, relative to the source bounds. Nearest uses lo; Bilinear
+// blends lo and hi with weight w/256; BoxFilter averages lo to hi inclusive.
+type axisMap struct {
+	lo, hi []int
+	w      []uint32
+}
+
+// newAxisMap maps n output pixels starting at offset within a scaled size of
+// size pixels onto a source of srcSize pixels
+func newAxisMap(filter Filter, offset, n, size, srcSize int) axisMap {
+	m := axisMap{lo: make([]int, n), hi: make([]int, n), w: make([]uint32, n)}
+	for i := 0; i < n; i++ {
+		o := offset + i
+		switch filter {
+		case Bilinear:
+			// Source position of the output pixel center, in 1/256 pixels
+			p := ((2*o+1)*srcSize*256)/(2*size) - 128
+			if p < 0 {
+				p = 0
+			}
+			m.lo[i], m.w[i] = p/256, uint32(p%256)
+			m.hi[i] = m.lo[i] + 1
+			if m.hi[i] >= srcSize {
+				m.lo[i], m.hi[i], m.w[i] = srcSize-1, srcSize-1, 0
+			}
+		case BoxFilter:
+			m.lo[i] = o * srcSize / size
+			m.hi[i] = ((o+1)*srcSize+size-1)/size - 1
+			if m.hi[i] < m.lo[i] {
+				m.hi[i] = m.lo[i]
+			}
+		default:
+			m.lo[i] = (2*o + 1) * srcSize / (2 * size)
+			m.hi[i] = m.lo[i]
+		}
+	}
+	return m
+}
+
+// lerpRGBA blends a towards b by w/256
+func lerpRGBA(a, b color.RGBA, w uint32) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8((uint32(x)*(256-w) + uint32(y)*w) >> 8)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// rowCache converts source rows on demand and keeps them until rows above
+// them are no longer needed. Rows are relative to the image bounds.
+type rowCache struct {
+	img  image.Image
+	rows map[int][]color.RGBA
+	free [][]color.RGBA
+}
+
+func (c *rowCache) get(y int) []color.RGBA {
+	if row, ok := c.rows[y]; ok {
+		return row
+	}
+	var row []color.RGBA
+	if n := len(c.free); n > 0 {
+		row, c.free = c.free[n-1], c.free[:n-1]
+	} else {
+		row = make([]color.RGBA, c.img.Bounds().Dx())
+	}
+	readRow(c.img, y, row)
+	c.rows[y] = row
+	return row
+}
+
+// drop releases the rows above y for reuse
+func (c *rowCache) drop(y int) {
+	for k, row := range c.rows {
+		if k < y {
+			c.free = append(c.free, row)
+			delete(c.rows, k)
+		}
+	}
+}
+
+// readRow converts row y of img, relative to its bounds, into dst
+func readRow(img image.Image, y int, dst []color.RGBA) {
+	b := img.Bounds()
+	y += b.Min.Y
+	switch m := img.(type) {
+	case *image.RGBA:
+		p := m.Pix[m.PixOffset(b.Min.X, y):]
+		for x := range dst {
+			dst[x] = color.RGBA{p[x*4], p[x*4+1], p[x*4+2], p[x*4+3]}
+		}
+	case *image.YCbCr:
+		yi := m.YOffset(b.Min.X, y)
+		for x := range dst {
+			ci := m.COffset(b.Min.X+x, y)
+			r, g, b := color.YCbCrToRGB(m.Y[yi+x], m.Cb[ci], m.Cr[ci])
+			dst[x] = color.RGBA{r, g, b, 0xFF}
+		}
+	default:
+		for x := range dst {
+			dst[x] = color.RGBAModel.Convert(img.At(b.Min.X+x, y)).(color.RGBA)
+		}
+	}
+}
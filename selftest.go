@@ -0,0 +1,171 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+
+	"periph.io/x/conn/v3/physic"
+)
+
+// SelfTestResult is the outcome of one SelfTest pattern.
+type SelfTestResult struct {
+	Pattern    string
+	Pixels     int         // Pixels compared
+	Mismatches int         // Pixels read back differently
+	First      image.Point // First mismatching pixel, if any
+}
+
+// testPattern is a pixel pattern written by SelfTest, as RGB565 values
+type testPattern struct {
+	name  string
+	pixel func(x, y int) uint16
+}
+
+// The patterns toggle every data line both ways, in sequences that stress
+// crosstalk and timing
+var testPatterns = []testPattern{
+	{"black", func(x, y int) uint16 { return 0 }},
+	{"white", func(x, y int) uint16 { return 0xFFFF }},
+	{"checker", func(x, y int) uint16 {
+		if (x+y)&1 == 0 {
+			return 0x5555
+		}
+		return 0xAAAA
+	}},
+	{"walking ones", func(x, y int) uint16 { return 1 << ((x + y) % 16) }},
+	{"walking zeros", func(x, y int) uint16 { return ^uint16(1 << ((x + y) % 16)) }},
+	{"random", func(x, y int) uint16 {
+		// xorshift of the position, so the check needs no copy of what was sent
+		v := uint32(y)<<16 | uint32(x) | 1
+		v ^= v << 13
+		v ^= v >> 17
+		v ^= v << 5
+		return uint16(v ^ v>>16)
+	}},
+}
+
+// SelfTest writes test patterns to r, clipped to the display, and reads each
+// back through RAMRD, counting the pixels that differ. Mismatches show that
+// the bus runs too fast for the wiring, or that reading is not wired at all;
+// see Capture for the requirements. The patterns stay on screen, so redraw
+// r afterwards.
+func (d *Device) SelfTest(r image.Rectangle) ([]SelfTestResult, error) {
+	return d.selfTest(r, nil, nil)
+}
+
+// selfTest runs SelfTest, calling beforeRead and afterRead, if set, around
+// each readback
+func (d *Device) selfTest(r image.Rectangle, beforeRead, afterRead func() error) ([]SelfTestResult, error) {
+	r = r.Intersect(d.bounds())
+	if r.Empty() {
+		return nil, errors.New("self-test rectangle outside display area")
+	}
+	var results []SelfTestResult
+	for _, p := range testPatterns {
+		err := d.blitRows(int16(r.Min.X), int16(r.Min.Y), int16(r.Dx()), int16(r.Dy()), func(row int, dst []byte) {
+			for i := 0; i < r.Dx(); i++ {
+				put565(dst[i*2:], p.pixel(r.Min.X+i, r.Min.Y+row))
+			}
+		})
+		if err != nil {
+			return results, err
+		}
+
+		if beforeRead != nil {
+			if err := beforeRead(); err != nil {
+				return results, err
+			}
+		}
+		img, err := d.readPixels(r)
+		if afterRead != nil {
+			if err := afterRead(); err != nil {
+				return results, err
+			}
+		}
+		if err != nil {
+			return results, err
+		}
+
+		res := SelfTestResult{Pattern: p.name, Pixels: r.Dx() * r.Dy()}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				i := img.PixOffset(x, y)
+				if uint16(img.Pix[i])<<8|uint16(img.Pix[i+1]) == p.pixel(x, y) {
+					continue
+				}
+				if res.Mismatches == 0 {
+					res.First = image.Pt(x, y)
+				}
+				res.Mismatches++
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// SpeedLimiter changes the clock of a connected bus. spi.PortCloser
+// implements it; on Linux spidev the new limit applies from the next
+// transfer.
+type SpeedLimiter interface {
+	LimitSpeed(f physic.Frequency) error
+}
+
+// AutoTuneOptions configures AutoTune.
+type AutoTuneOptions struct {
+	Max, Min physic.Frequency // Clock range to search, Max first
+	// ReadSpeed is the clock for reading the patterns back, so that only
+	// writing is tested at each step. Zero reads at the tested clock, which
+	// then has to hold for reads too.
+	ReadSpeed physic.Frequency
+	Rect      image.Rectangle // Area to test, the whole display when empty
+	Rounds    int             // Clean runs required at a clock, 2 when 0
+}
+
+// AutoTune looks for the highest bus clock at which pixels arrive intact. It
+// runs SelfTest at opts.Max, then at 10% lower clocks until opts.Rounds runs
+// in a row come back clean, and returns that clock, which is left set. It
+// fails when even opts.Min gives mismatches; the clock is then left at
+// opts.Min.
+func (d *Device) AutoTune(bus SpeedLimiter, opts AutoTuneOptions) (physic.Frequency, error) {
+	if opts.Max <= 0 || opts.Min <= 0 || opts.Min > opts.Max {
+		return 0, errors.New("autotune: invalid clock range")
+	}
+	if opts.Rect.Empty() {
+		opts.Rect = d.bounds()
+	}
+	if opts.Rounds <= 0 {
+		opts.Rounds = 2
+	}
+
+	for f := opts.Max; ; f = f * 9 / 10 {
+		if f < opts.Min {
+			f = opts.Min
+		}
+		var beforeRead, afterRead func() error
+		if opts.ReadSpeed > 0 {
+			beforeRead = func() error { return bus.LimitSpeed(opts.ReadSpeed) }
+			afterRead = func() error { return bus.LimitSpeed(f) }
+		}
+		if err := bus.LimitSpeed(f); err != nil {
+			return 0, err
+		}
+
+		clean := true
+		for i := 0; i < opts.Rounds && clean; i++ {
+			results, err := d.selfTest(opts.Rect, beforeRead, afterRead)
+			if err != nil {
+				return 0, err
+			}
+			for _, r := range results {
+				clean = clean && r.Mismatches == 0
+			}
+		}
+		if clean {
+			return f, nil
+		}
+		if f == opts.Min {
+			return 0, errors.New("autotune: readback mismatches even at the lowest clock")
+		}
+	}
+}
@@ -0,0 +1,605 @@
+package gc9307
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// All shapes are decomposed into horizontal and vertical spans, and filled
+// shapes into rectangles, so they go through the FillRectangle window path
+// instead of setting one pixel at a time. Shapes are clipped to the display.
+
+// fillClipped fills a rectangle, clipped to the display area
+func (d *Device) fillClipped(x, y, w, h int, c color.RGBA) {
+	sw, sh := d.Size()
+	if x < 0 {
+		w += x
+		x = 0
+	}
+	if y < 0 {
+		h += y
+		y = 0
+	}
+	if x+w > int(sw) {
+		w = int(sw) - x
+	}
+	if y+h > int(sh) {
+		h = int(sh) - y
+	}
+	if w <= 0 || h <= 0 {
+		return
+	}
+	d.FillRectangle(int16(x), int16(y), int16(w), int16(h), c)
+}
+
+// runBuilder merges consecutive pixels of a path into horizontal or vertical
+// runs and draws each run with a single rectangle
+type runBuilder struct {
+	d      *Device
+	c      color.RGBA
+	n      int // Pixels in the current run
+	x0, y0 int // First pixel of the run
+	x1, y1 int // Last pixel of the run
+}
+
+func (b *runBuilder) add(x, y int) {
+	if b.n > 0 && x == b.x1 && y == b.y1 {
+		// Same pixel again, e.g. where two octants meet
+		return
+	}
+	if b.n > 0 && !b.extends(x, y) {
+		b.flush()
+	}
+	if b.n == 0 {
+		b.x0, b.y0 = x, y
+	}
+	b.x1, b.y1 = x, y
+	b.n++
+}
+
+// extends reports whether (x, y) continues the current run in a straight
+// horizontal or vertical line
+func (b *runBuilder) extends(x, y int) bool {
+	dx, dy := x-b.x1, y-b.y1
+	if b.n == 1 {
+		// The second pixel decides the direction of the run
+		return (dy == 0 && abs(dx) == 1) || (dx == 0 && abs(dy) == 1)
+	}
+	return dx == sign(b.x1-b.x0) && dy == sign(b.y1-b.y0)
+}
+
+func (b *runBuilder) flush() {
+	if b.n == 0 {
+		return
+	}
+	x0, x1 := b.x0, b.x1
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	y0, y1 := b.y0, b.y1
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	b.d.fillClipped(x0, y0, x1-x0+1, y1-y0+1, b.c)
+	b.n = 0
+}
+
+// span is a horizontal run of pixels from x0 to x1 inclusive
+type span struct {
+	x0, x1 int
+}
+
+// rectMerger turns the spans of consecutive rows into rectangles, merging
+// rows whose spans are identical
+type rectMerger struct {
+	d     *Device
+	c     color.RGBA
+	y0    int // First row of the pending spans
+	rows  int // Number of rows with the pending spans
+	spans []span
+}
+
+// row adds the spans of row y; rows must be added top to bottom without gaps
+func (m *rectMerger) row(y int, spans []span) {
+	if m.rows > 0 && y == m.y0+m.rows && sameSpans(m.spans, spans) {
+		m.rows++
+		return
+	}
+	m.flush()
+	m.spans = append(m.spans[:0], spans...)
+	m.y0 = y
+	m.rows = 1
+}
+
+func (m *rectMerger) flush() {
+	for _, s := range m.spans {
+		m.d.fillClipped(s.x0, m.y0, s.x1-s.x0+1, m.rows, m.c)
+	}
+	m.spans = m.spans[:0]
+	m.rows = 0
+}
+
+func sameSpans(a, b []span) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DrawLine draws a line between two points of any angle
+func (d *Device) DrawLine(x0, y0, x1, y1 int16, c color.RGBA) {
+	b := runBuilder{d: d, c: c}
+	bresenham(int(x0), int(y0), int(x1), int(y1), b.add)
+	b.flush()
+}
+
+// bresenham calls plot for each pixel of the line, in order
+func bresenham(x0, y0, x1, y1 int, plot func(x, y int)) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// DrawRectangle draws the outline of a rectangle
+func (d *Device) DrawRectangle(x, y, w, h int16, c color.RGBA) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	X, Y, W, H := int(x), int(y), int(w), int(h)
+	d.fillClipped(X, Y, W, 1, c)
+	if H > 1 {
+		d.fillClipped(X, Y+H-1, W, 1, c)
+	}
+	if H > 2 {
+		d.fillClipped(X, Y+1, 1, H-2, c)
+		if W > 1 {
+			d.fillClipped(X+W-1, Y+1, 1, H-2, c)
+		}
+	}
+}
+
+// FillRectangleClipped fills a rectangle, clipping it to the display instead
+// of rejecting coordinates outside of it like FillRectangle does
+func (d *Device) FillRectangleClipped(x, y, w, h int16, c color.RGBA) {
+	d.fillClipped(int(x), int(y), int(w), int(h), c)
+}
+
+// circleOctant returns the offsets of the first octant of a circle of radius
+// r, from (r, 0) to the 45 degree diagonal, in order
+func circleOctant(r int) []image.Point {
+	var pts []image.Point
+	x, y := r, 0
+	e := 1 - r
+	for x >= y {
+		pts = append(pts, image.Point{x, y})
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+	return pts
+}
+
+// octantTransforms maps a first octant offset (x, y) to each of the eight
+// octants, going clockwise on screen from 3 o'clock. Odd octants run the
+// points in reverse to keep every path continuous.
+var octantTransforms = [8]func(p image.Point) image.Point{
+	func(p image.Point) image.Point { return image.Point{p.X, p.Y} },
+	func(p image.Point) image.Point { return image.Point{p.Y, p.X} },
+	func(p image.Point) image.Point { return image.Point{-p.Y, p.X} },
+	func(p image.Point) image.Point { return image.Point{-p.X, p.Y} },
+	func(p image.Point) image.Point { return image.Point{-p.X, -p.Y} },
+	func(p image.Point) image.Point { return image.Point{-p.Y, -p.X} },
+	func(p image.Point) image.Point { return image.Point{p.Y, -p.X} },
+	func(p image.Point) image.Point { return image.Point{p.X, -p.Y} },
+}
+
+// circlePath calls plot for every pixel of a circle outline, octant by
+// octant, with the pixel angle in degrees (0 at 3 o'clock, clockwise)
+func circlePath(r int, plot func(x, y int, angle float64), octantDone func()) {
+	pts := circleOctant(r)
+	for o, t := range octantTransforms {
+		for i := range pts {
+			p := pts[i]
+			if o%2 == 1 {
+				p = pts[len(pts)-1-i]
+			}
+			q := t(p)
+			a := math.Atan2(float64(q.Y), float64(q.X)) * 180 / math.Pi
+			if a < 0 {
+				a += 360
+			}
+			plot(q.X, q.Y, a)
+		}
+		octantDone()
+	}
+}
+
+// DrawCircle draws the outline of a circle
+func (d *Device) DrawCircle(x0, y0, r int16, c color.RGBA) {
+	if r < 0 {
+		return
+	}
+	cx, cy := int(x0), int(y0)
+	b := runBuilder{d: d, c: c}
+	circlePath(int(r), func(x, y int, _ float64) { b.add(cx+x, cy+y) }, b.flush)
+}
+
+// DrawArc draws part of a circle outline, from startAngle to endAngle in
+// degrees. 0 is at 3 o'clock and angles grow clockwise, as y grows downwards.
+func (d *Device) DrawArc(x0, y0, r int16, startAngle, endAngle float64, c color.RGBA) {
+	if r < 0 {
+		return
+	}
+	start := math.Mod(startAngle, 360)
+	if start < 0 {
+		start += 360
+	}
+	sweep := endAngle - startAngle
+	if sweep >= 360 || sweep <= -360 {
+		d.DrawCircle(x0, y0, r, c)
+		return
+	}
+	if sweep < 0 {
+		start = math.Mod(start+sweep+360, 360)
+		sweep = -sweep
+	}
+
+	cx, cy := int(x0), int(y0)
+	b := runBuilder{d: d, c: c}
+	circlePath(int(r), func(x, y int, a float64) {
+		if math.Mod(a-start+360, 360) <= sweep {
+			b.add(cx+x, cy+y)
+		} else {
+			b.flush()
+		}
+	}, b.flush)
+}
+
+// FillCircle draws a filled circle
+func (d *Device) FillCircle(x0, y0, r int16, c color.RGBA) {
+	if r < 0 {
+		return
+	}
+	d.fillRoundedRect(int(x0)-int(r), int(y0)-int(r), 2*int(r)+1, 2*int(r)+1, int(r), c)
+}
+
+// DrawRoundedRectangle draws the outline of a rectangle with rounded corners
+// of radius r
+func (d *Device) DrawRoundedRectangle(x, y, w, h, r int16, c color.RGBA) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	X, Y, W, H, R := int(x), int(y), int(w), int(h), clampRadius(int(r), int(w), int(h))
+	if R == 0 {
+		d.DrawRectangle(x, y, w, h, c)
+		return
+	}
+
+	// Straight edges between the corners
+	d.fillClipped(X+R, Y, W-2*R, 1, c)
+	d.fillClipped(X+R, Y+H-1, W-2*R, 1, c)
+	d.fillClipped(X, Y+R, 1, H-2*R, c)
+	d.fillClipped(X+W-1, Y+R, 1, H-2*R, c)
+
+	// Each pair of octants forms one corner, centered on the corner circle
+	centers := [4]image.Point{
+		{X + W - 1 - R, Y + H - 1 - R}, // bottom right
+		{X + R, Y + H - 1 - R},         // bottom left
+		{X + R, Y + R},                 // top left
+		{X + W - 1 - R, Y + R},         // top right
+	}
+	b := runBuilder{d: d, c: c}
+	octant := 0
+	circlePath(R, func(x, y int, _ float64) {
+		ctr := centers[octant/2]
+		b.add(ctr.X+x, ctr.Y+y)
+	}, func() {
+		b.flush()
+		octant++
+	})
+}
+
+// FillRoundedRectangle draws a filled rectangle with rounded corners of
+// radius r
+func (d *Device) FillRoundedRectangle(x, y, w, h, r int16, c color.RGBA) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	d.fillRoundedRect(int(x), int(y), int(w), int(h), clampRadius(int(r), int(w), int(h)), c)
+}
+
+func clampRadius(r, w, h int) int {
+	if r < 0 {
+		r = 0
+	}
+	if 2*r+1 > w {
+		r = (w - 1) / 2
+	}
+	if 2*r+1 > h {
+		r = (h - 1) / 2
+	}
+	return r
+}
+
+// fillRoundedRect fills a rounded rectangle row by row; the rows between the
+// corners merge into a single rectangle
+func (d *Device) fillRoundedRect(x, y, w, h, r int, c color.RGBA) {
+	// inset[dy] is how far the corner pulls in row dy from the top edge
+	inset := make([]int, r+1)
+	for i := range inset {
+		inset[i] = r
+	}
+	for _, p := range circleOctant(r) {
+		// Each octant point widens the rows it touches
+		for _, q := range [2]image.Point{p, {p.Y, p.X}} {
+			row := r - q.Y
+			if in := r - q.X; in < inset[row] {
+				inset[row] = in
+			}
+		}
+	}
+
+	m := rectMerger{d: d, c: c}
+	row := make([]span, 1)
+	for dy := 0; dy < h; dy++ {
+		in := 0
+		if dy < r {
+			in = inset[dy]
+		} else if dy >= h-r {
+			in = inset[h-1-dy]
+		}
+		row[0] = span{x + in, x + w - 1 - in}
+		m.row(y+dy, row)
+	}
+	m.flush()
+}
+
+// ellipseQuadrant returns the offsets of the bottom right quadrant of an
+// ellipse, from (rx, 0) to (0, ry), in order
+func ellipseQuadrant(rx, ry int) []image.Point {
+	var pts []image.Point
+	if rx == 0 || ry == 0 {
+		for x := rx; x >= 0; x-- {
+			pts = append(pts, image.Point{x, 0})
+		}
+		for y := 1; y <= ry; y++ {
+			pts = append(pts, image.Point{0, y})
+		}
+		return pts
+	}
+
+	// Midpoint ellipse algorithm, computed from the top so both regions use
+	// the usual formulation, then reversed
+	a2, b2 := int64(rx)*int64(rx), int64(ry)*int64(ry)
+	x, y := int64(0), int64(ry)
+	var rev []image.Point
+	p := b2 - a2*int64(ry) + a2/4
+	for b2*x < a2*y {
+		rev = append(rev, image.Point{int(x), int(y)})
+		x++
+		if p < 0 {
+			p += b2 * (2*x + 1)
+		} else {
+			y--
+			p += b2*(2*x+1) - 2*a2*y
+		}
+	}
+	p = b2*(2*x+1)*(2*x+1)/4 + a2*(y-1)*(y-1) - a2*b2
+	for y >= 0 {
+		rev = append(rev, image.Point{int(x), int(y)})
+		y--
+		if p > 0 {
+			p += a2 * (1 - 2*y)
+		} else {
+			x++
+			p += b2*2*x + a2*(1-2*y)
+		}
+	}
+	for i := len(rev) - 1; i >= 0; i-- {
+		pts = append(pts, rev[i])
+	}
+	return pts
+}
+
+// DrawEllipse draws the outline of an ellipse with radii rx and ry
+func (d *Device) DrawEllipse(x0, y0, rx, ry int16, c color.RGBA) {
+	if rx < 0 || ry < 0 {
+		return
+	}
+	cx, cy := int(x0), int(y0)
+	pts := ellipseQuadrant(int(rx), int(ry))
+	signs := [4]image.Point{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	b := runBuilder{d: d, c: c}
+	for q, s := range signs {
+		for i := range pts {
+			p := pts[i]
+			if q%2 == 1 {
+				p = pts[len(pts)-1-i]
+			}
+			b.add(cx+s.X*p.X, cy+s.Y*p.Y)
+		}
+		b.flush()
+	}
+}
+
+// FillEllipse draws a filled ellipse with radii rx and ry
+func (d *Device) FillEllipse(x0, y0, rx, ry int16, c color.RGBA) {
+	if rx < 0 || ry < 0 {
+		return
+	}
+	cx, cy := int(x0), int(y0)
+	half := make([]int, int(ry)+1)
+	for i := range half {
+		half[i] = -1
+	}
+	for _, p := range ellipseQuadrant(int(rx), int(ry)) {
+		if p.X > half[p.Y] {
+			half[p.Y] = p.X
+		}
+	}
+
+	m := rectMerger{d: d, c: c}
+	row := make([]span, 1)
+	for dy := -int(ry); dy <= int(ry); dy++ {
+		hw := half[abs(dy)]
+		row[0] = span{cx - hw, cx + hw}
+		m.row(cy+dy, row)
+	}
+	m.flush()
+}
+
+// DrawTriangle draws the outline of a triangle
+func (d *Device) DrawTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	d.DrawPolygon([]image.Point{
+		{int(x0), int(y0)}, {int(x1), int(y1)}, {int(x2), int(y2)},
+	}, c)
+}
+
+// FillTriangle draws a filled triangle
+func (d *Device) FillTriangle(x0, y0, x1, y1, x2, y2 int16, c color.RGBA) {
+	d.FillPolygon([]image.Point{
+		{int(x0), int(y0)}, {int(x1), int(y1)}, {int(x2), int(y2)},
+	}, c)
+}
+
+// DrawPolygon draws the outline of a closed polygon
+func (d *Device) DrawPolygon(points []image.Point, c color.RGBA) {
+	if len(points) == 0 {
+		return
+	}
+	b := runBuilder{d: d, c: c}
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		bresenham(p.X, p.Y, q.X, q.Y, b.add)
+		b.flush()
+	}
+}
+
+// FillPolygon draws a filled polygon, using the even-odd rule for self
+// intersecting outlines. The outline pixels, as drawn by DrawPolygon, are
+// part of the filled area.
+func (d *Device) FillPolygon(points []image.Point, c color.RGBA) {
+	if len(points) == 0 {
+		return
+	}
+	_, sh := d.Size()
+	minY, maxY := points[0].Y, points[0].Y
+	for _, p := range points {
+		if p.Y < minY {
+			minY = p.Y
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
+	}
+	if minY < 0 {
+		minY = 0
+	}
+	if maxY >= int(sh) {
+		maxY = int(sh) - 1
+	}
+
+	// Spans of the outline itself, so thin parts and edges are not lost
+	edges := make(map[int][]span)
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		bresenham(p.X, p.Y, q.X, q.Y, func(x, y int) {
+			edges[y] = append(edges[y], span{x, x})
+		})
+	}
+
+	m := rectMerger{d: d, c: c}
+	var xs []float64
+	var row []span
+	for y := minY; y <= maxY; y++ {
+		// Crossings of the pixel center line with every edge
+		xs = xs[:0]
+		fy := float64(y)
+		for i, p := range points {
+			q := points[(i+1)%len(points)]
+			if (p.Y <= y) == (q.Y <= y) {
+				continue
+			}
+			t := (fy - float64(p.Y)) / float64(q.Y-p.Y)
+			xs = append(xs, float64(p.X)+t*float64(q.X-p.X))
+		}
+		sort.Float64s(xs)
+
+		row = row[:0]
+		for i := 0; i+1 < len(xs); i += 2 {
+			row = append(row, span{int(math.Ceil(xs[i])), int(math.Floor(xs[i+1]))})
+		}
+		row = mergeSpans(append(row, edges[y]...))
+		m.row(y, row)
+	}
+	m.flush()
+}
+
+// mergeSpans sorts spans and joins the overlapping or touching ones
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].x0 < spans[j].x0 })
+	out := spans[:0]
+	for _, s := range spans {
+		if s.x1 < s.x0 {
+			continue
+		}
+		if n := len(out); n > 0 && s.x0 <= out[n-1].x1+1 {
+			if s.x1 > out[n-1].x1 {
+				out[n-1].x1 = s.x1
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
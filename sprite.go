@@ -0,0 +1,196 @@
+package gc9307
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// SpriteSheet is a set of frames of the same size, laid out in a grid in an
+// RGB565 atlas. The atlas is stored in the byte order sent to the panel, so
+// frames are copied to the bus without any conversion.
+type SpriteSheet struct {
+	FrameWidth, FrameHeight int
+	Width, Height           int    // Size of the atlas
+	Pix                     []byte // Atlas pixels, 2 bytes each as RGBATo565BGR, big endian
+}
+
+// NewSpriteSheet converts img to an atlas of frames of the given size,
+// numbered left to right, then top to bottom. Partial frames at the right and
+// bottom edges are dropped.
+func NewSpriteSheet(img image.Image, frameWidth, frameHeight int) (*SpriteSheet, error) {
+	b := img.Bounds()
+	if frameWidth <= 0 || frameHeight <= 0 || b.Dx() < frameWidth || b.Dy() < frameHeight {
+		return nil, errors.New("frame size does not fit the image")
+	}
+	s := &SpriteSheet{
+		FrameWidth:  frameWidth,
+		FrameHeight: frameHeight,
+		Width:       b.Dx() / frameWidth * frameWidth,
+		Height:      b.Dy() / frameHeight * frameHeight,
+	}
+	s.Pix = make([]byte, s.Width*s.Height*2)
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			c := color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+			put565(s.Pix[(y*s.Width+x)*2:], RGBATo565BGR(c))
+		}
+	}
+	return s, nil
+}
+
+// Len returns the number of frames.
+func (s *SpriteSheet) Len() int {
+	return (s.Width / s.FrameWidth) * (s.Height / s.FrameHeight)
+}
+
+// row returns row y of frame f, from column x to the end of the frame
+func (s *SpriteSheet) row(f, x, y int) []byte {
+	cols := s.Width / s.FrameWidth
+	ax := (f%cols)*s.FrameWidth + x
+	ay := (f/cols)*s.FrameHeight + y
+	i := (ay*s.Width + ax) * 2
+	return s.Pix[i : i+(s.FrameWidth-x)*2]
+}
+
+// DrawSprite draws frame f of s with its top left corner at (x, y), clipped
+// to the display, through a single address window.
+func (d *Device) DrawSprite(x, y int16, s *SpriteSheet, f int) error {
+	if f < 0 || f >= s.Len() {
+		return errors.New("sprite frame out of range")
+	}
+	return d.drawFrames(int(x), int(y), s, []int{f})
+}
+
+// DrawSpriteKey draws frame f of s like DrawSprite, except for the pixels of
+// color key, which are left untouched. Each row is split into runs of opaque
+// pixels, sent straight from the atlas.
+func (d *Device) DrawSpriteKey(x, y int16, s *SpriteSheet, f int, key color.RGBA) error {
+	if f < 0 || f >= s.Len() {
+		return errors.New("sprite frame out of range")
+	}
+	k := RGBATo565BGR(key)
+	k1, k2 := uint8(k>>8), uint8(k)
+	vis := image.Rect(int(x), int(y), int(x)+s.FrameWidth, int(y)+s.FrameHeight).Intersect(d.bounds())
+	for py := vis.Min.Y; py < vis.Max.Y; py++ {
+		row := s.row(f, vis.Min.X-int(x), py-int(y))[:vis.Dx()*2]
+		for i := 0; i < vis.Dx(); {
+			// Skip keyed pixels, then send the following opaque run
+			if row[i*2] == k1 && row[i*2+1] == k2 {
+				i++
+				continue
+			}
+			j := i + 1
+			for j < vis.Dx() && (row[j*2] != k1 || row[j*2+1] != k2) {
+				j++
+			}
+			d.setWindow(int16(vis.Min.X+i), int16(py), int16(j-i), 1)
+			if err := d.transport.WritePixels(row[i*2:j*2], 1); err != nil {
+				return err
+			}
+			i = j
+		}
+	}
+	return nil
+}
+
+// drawFrames draws frames side by side from (x, y), clipped to the display,
+// as one window
+func (d *Device) drawFrames(x, y int, s *SpriteSheet, frames []int) error {
+	vis := image.Rect(x, y, x+len(frames)*s.FrameWidth, y+s.FrameHeight).Intersect(d.bounds())
+	if vis.Empty() {
+		return nil
+	}
+	return d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+		fy := vis.Min.Y - y + row
+		for px := vis.Min.X; px < vis.Max.X; {
+			lx := px - x
+			src := s.row(frames[lx/s.FrameWidth], lx%s.FrameWidth, fy)
+			if n := (vis.Max.X - px) * 2; n < len(src) {
+				src = src[:n]
+			}
+			px += copy(dst[(px-vis.Min.X)*2:], src) / 2
+		}
+	})
+}
+
+// TileMap is a grid of tiles taken from a SpriteSheet. Draw only sends the
+// tiles whose index changed since the previous Draw.
+type TileMap struct {
+	Sheet         *SpriteSheet
+	Columns, Rows int
+	X, Y          int   // Screen position of the top left corner
+	tiles         []int // Current tile indexes, -1 for none
+	drawn         []int // Tile indexes on the panel, -2 when unknown
+}
+
+// NewTileMap returns a map of columns x rows empty tiles, drawn at (x, y).
+func NewTileMap(sheet *SpriteSheet, columns, rows, x, y int) *TileMap {
+	m := &TileMap{
+		Sheet:   sheet,
+		Columns: columns,
+		Rows:    rows,
+		X:       x,
+		Y:       y,
+		tiles:   make([]int, columns*rows),
+		drawn:   make([]int, columns*rows),
+	}
+	for i := range m.tiles {
+		m.tiles[i] = -1
+	}
+	m.Invalidate()
+	return m
+}
+
+// Set sets the tile at (col, row) to frame tile of the sheet, -1 for none.
+// Cells without a tile are not drawn.
+func (m *TileMap) Set(col, row, tile int) {
+	if col >= 0 && row >= 0 && col < m.Columns && row < m.Rows {
+		m.tiles[row*m.Columns+col] = tile
+	}
+}
+
+// Tile returns the tile at (col, row).
+func (m *TileMap) Tile(col, row int) int {
+	if col < 0 || row < 0 || col >= m.Columns || row >= m.Rows {
+		return -1
+	}
+	return m.tiles[row*m.Columns+col]
+}
+
+// Invalidate makes the next Draw send every tile, e.g. after something else
+// was drawn over the map or it was moved.
+func (m *TileMap) Invalidate() {
+	for i := range m.drawn {
+		m.drawn[i] = -2
+	}
+}
+
+// Draw sends the tiles that changed since the last call. Horizontally
+// adjacent changed tiles share one address window.
+func (m *TileMap) Draw(d *Device) error {
+	n := m.Sheet.Len()
+	for row := 0; row < m.Rows; row++ {
+		line := m.tiles[row*m.Columns : (row+1)*m.Columns]
+		drawn := m.drawn[row*m.Columns : (row+1)*m.Columns]
+		for col := 0; col < m.Columns; {
+			t := line[col]
+			if t == drawn[col] || t < 0 || t >= n {
+				col++
+				continue
+			}
+			end := col + 1
+			for end < m.Columns && line[end] != drawn[end] && line[end] >= 0 && line[end] < n {
+				end++
+			}
+			x := m.X + col*m.Sheet.FrameWidth
+			y := m.Y + row*m.Sheet.FrameHeight
+			if err := d.drawFrames(x, y, m.Sheet, line[col:end]); err != nil {
+				return err
+			}
+			copy(drawn[col:end], line[col:end])
+			col = end
+		}
+	}
+	return nil
+}
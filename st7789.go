@@ -39,10 +39,9 @@ func checkDMAAvailability() error {
 	return nil
 }
 
-// Device wraps an SPI connection.
+// Device wraps a connection to the display.
 type Device struct {
-	bus             spi.Conn
-	dcPin           gpio.PinOut
+	transport       Transport
 	resetPin        gpio.PinOut
 	csPin           gpio.PinOut
 	blPin           gpio.PinOut
@@ -65,6 +64,8 @@ type Device struct {
 	useDMA          bool
 	maxTransferSize int32
 	chunkSize       int32
+	window          windowCache   // Last programmed address window
+	shadow          *shadowTransport // Shadow framebuffer, nil when disabled
 }
 
 // Config is the configuration for the display
@@ -76,26 +77,45 @@ type Config struct {
 	ColumnOffset int16
 	FrameRate    FrameRate
 	VSyncLines   int16
-	UseCS        bool
+	UseCS        bool // Drive the CS pin given to New, needed for 4-wire reads
 	UseDMA       bool // Enable DMA transfers (default: true)
+	NoPackets    bool // Disable batching transfers with spi.Conn.TxPackets
+	Use9BitWords bool // 3-wire mode: the SPI connection supports 9 bits per word
+	KeepContents bool // Don't clear the screen, e.g. to capture what a running panel shows
 }
 
 // New creates a new gc9307 connection. The SPI wire must already be configured.
+//
+// A nil dcPin selects the 3-wire serial interface, where the D/C bit is sent
+// in front of every byte instead of on a separate pin.
 func New(bus spi.Conn, resetPin, dcPin, csPin, blPin gpio.PinOut) Device {
+	var t Transport
+	if dcPin == nil {
+		t = NewThreeWire(bus, false)
+	} else {
+		t = NewFourWire(bus, dcPin)
+	}
+	d := NewWithTransport(t, resetPin, blPin)
+	d.csPin = csPin
+	return d
+}
+
+// NewWithTransport creates a new gc9307 connection over any Transport, e.g.
+// a Recorder to test drawing code without hardware.
+func NewWithTransport(t Transport, resetPin, blPin gpio.PinOut) Device {
 	return Device{
-		bus:      bus,
-		dcPin:    dcPin,
-		resetPin: resetPin,
-		csPin:    csPin,
-		blPin:    blPin,
+		transport: t,
+		resetPin:  resetPin,
+		blPin:     blPin,
 	}
 }
 
+// initializedFile marks the display as initialized since boot, so that later
+// programs skip the reset. A variable so tests can move it.
+var initializedFile = "/tmp/pcat_display_initialized"
+
 // Configure initializes the display with default configuration
 func (d *Device) Configure(cfg Config) {
-	//touch a file to indicate that the display is initialized
-	initializedFile := "/tmp/pcat_display_initialized"
-
 	isInitialized := false
 	if _, err := os.Stat(initializedFile); err == nil {
 		isInitialized = true
@@ -149,6 +169,20 @@ func (d *Device) Configure(cfg Config) {
 		log.Println("Using original transfer mode")
 	}
 
+	// Pass the wire protocol settings down to the transport
+	var cs gpio.PinOut
+	if cfg.UseCS {
+		cs = d.csPin
+	}
+	if t, ok := d.transport.(configurable); ok {
+		t.configure(transportConfig{
+			usePackets:      !cfg.NoPackets,
+			nineBitWords:    cfg.Use9BitWords,
+			maxTransferSize: d.maxTransferSize,
+			csPin:           cs,
+		})
+	}
+
 	// Use original batch length calculation
 	d.batchLength = int32(d.width)
 	if d.height > d.width {
@@ -171,6 +205,9 @@ func (d *Device) Configure(cfg Config) {
 	// Pre-allocate command buffer for optimized window setup
 	d.commandBuffer = make([]uint8, 11) // Max command sequence size
 
+	// Whatever window the display had is unknown until we set one
+	d.window.invalidate()
+
 	//check if the display is already initialized
 	
 	if !isInitialized {
@@ -198,7 +235,9 @@ func (d *Device) Configure(cfg Config) {
 	d.SetRotation(d.rotation) // Memory orientation
 	
 	d.setWindow(0, 0, d.width, d.height)   // Full draw window
-	d.FillScreen(color.RGBA{0, 0, 0, 255}) // Clear screen
+	if !cfg.KeepContents {
+		d.FillScreen(color.RGBA{0, 0, 0, 255}) // Clear screen
+	}
 
 	
 	// Framerate
@@ -325,42 +364,34 @@ func (d *Device) SetPixel(x int16, y int16, c color.RGBA) {
 func (d *Device) setWindow(x, y, w, h int16) {
 	x += d.columnOffset
 	y += d.rowOffset
-	
-	// Optimized window setup with minimal SPI transactions
+
 	// Use pre-allocated buffer to avoid allocations
 	cmd := d.commandBuffer
-	
+
+	// Only resend the ranges that differ from what the display already has
+	sendColumns, sendRows := d.window.update(x, x+w-1, y, y+h-1)
+
 	// CASET command + coordinates
-	cmd[0] = CASET
-	cmd[1] = uint8(x >> 8)
-	cmd[2] = uint8(x)
-	cmd[3] = uint8((x + w - 1) >> 8)
-	cmd[4] = uint8(x + w - 1)
-	
-	// Send CASET command and data in 2 transactions
-	d.dcPin.Out(gpio.Low)   // Command mode
-	d.bus.Tx(cmd[:1], nil)
-	d.dcPin.Out(gpio.High)  // Data mode
-	d.bus.Tx(cmd[1:5], nil)
-	
+	if sendColumns {
+		cmd[0] = uint8(x >> 8)
+		cmd[1] = uint8(x)
+		cmd[2] = uint8((x + w - 1) >> 8)
+		cmd[3] = uint8(x + w - 1)
+		d.transport.WriteCommand(CASET, cmd[0:4])
+	}
+
 	// RASET command + coordinates
-	cmd[0] = RASET
-	cmd[1] = uint8(y >> 8)
-	cmd[2] = uint8(y)
-	cmd[3] = uint8((y + h - 1) >> 8)
-	cmd[4] = uint8(y + h - 1)
-	
-	// Send RASET command and data in 2 transactions
-	d.dcPin.Out(gpio.Low)   // Command mode
-	d.bus.Tx(cmd[:1], nil)
-	d.dcPin.Out(gpio.High)  // Data mode
-	d.bus.Tx(cmd[1:5], nil)
-	
-	// RAMWR command
-	cmd[0] = RAMWR
-	d.dcPin.Out(gpio.Low)   // Command mode
-	d.bus.Tx(cmd[:1], nil)
-	d.dcPin.Out(gpio.High)  // Data mode for following pixel data
+	if sendRows {
+		cmd[4] = uint8(y >> 8)
+		cmd[5] = uint8(y)
+		cmd[6] = uint8((y + h - 1) >> 8)
+		cmd[7] = uint8(y + h - 1)
+		d.transport.WriteCommand(RASET, cmd[4:8])
+	}
+
+	// RAMWR command, always needed to restart the memory write. Transports
+	// may hold the sequence back to send it with the pixel data.
+	d.transport.WriteCommand(RAMWR, nil)
 }
 
 // FillRectangle fills a rectangle at a given coordinates with a color
@@ -379,14 +410,16 @@ func (d *Device) FillRectangle(x, y, width, height int16, c color.RGBA) error {
 		d.buffer[i*2] = c1
 		d.buffer[i*2+1] = c2
 	}
+	// The buffer content never changes, so all full batches are handed to
+	// the transport at once
 	j := int32(width) * int32(height)
-	for j > 0 {
-		if j >= d.batchLength {
-			d.Tx(d.buffer, false)
-		} else {
-			d.Tx(d.buffer[:j*2], false)
+	if full := j / d.batchLength; full > 0 {
+		if err := d.transport.WritePixels(d.buffer, int(full)); err != nil {
+			return err
 		}
-		j -= d.batchLength
+	}
+	if rest := j % d.batchLength; rest > 0 {
+		return d.transport.WritePixels(d.buffer[:rest*2], 1)
 	}
 	return nil
 }
@@ -443,7 +476,10 @@ func (d *Device) fillRectangleWithBufferDMA(width, height int16, buffer []color.
 			}
 		}
 		
-		d.TxWithCS(dmaBuffer[:currentBatch*2], false, false)
+		if err := d.transport.WritePixels(dmaBuffer[:currentBatch*2], 1); err != nil {
+			d.EndTransaction()
+			return err
+		}
 		k -= currentBatch
 		offset += currentBatch
 	}
@@ -470,10 +506,13 @@ func (d *Device) fillRectangleWithBufferOriginal(width, height int16, buffer []c
 				d.buffer[i*2+1] = c2
 			}
 		}
-		if k >= d.batchLength {
-			d.TxWithCS(d.buffer, false, false)
-		} else {
-			d.TxWithCS(d.buffer[:k*2], false, false)
+		n := k
+		if n > d.batchLength {
+			n = d.batchLength
+		}
+		if err := d.transport.WritePixels(d.buffer[:n*2], 1); err != nil {
+			d.EndTransaction()
+			return err
 		}
 		k -= d.batchLength
 		offset += d.batchLength
@@ -484,9 +523,12 @@ func (d *Device) fillRectangleWithBufferOriginal(width, height int16, buffer []c
 	return nil
 }
 
-// FillRectangleWithImage fills a rectangle on the display using an *image.RGBA as the framebuffer.
-// It assumes that fb's dimensions (Dx x Dy) exactly match the given width and height.
-func (d *Device) FillRectangleWithImage(x, y, width, height int16, fb *image.RGBA) error {
+// FillRectangleWithImage fills a rectangle on the display with an image.
+// The image's dimensions (Dx x Dy) must exactly match the given width and
+// height; it is read from fb.Bounds().Min, so sub-images work as expected.
+// *image.RGBA, *image.NRGBA, *image.YCbCr, *image.Gray and *image.Paletted
+// are converted straight from their pixel data, other types through At.
+func (d *Device) FillRectangleWithImage(x, y, width, height int16, fb image.Image) error {
 	// Get the display size.
 	i, j := d.Size()
 	if x < 0 || y < 0 || width <= 0 || height <= 0 ||
@@ -510,7 +552,7 @@ func (d *Device) FillRectangleWithImage(x, y, width, height int16, fb *image.RGB
 }
 
 // fillRectangleWithImageDMA uses larger batches optimized for DMA
-func (d *Device) fillRectangleWithImageDMA(width, height int16, fb *image.RGBA) error {
+func (d *Device) fillRectangleWithImageDMA(width, height int16, fb image.Image) error {
 	// For DMA mode, use larger batch sizes but keep the same logic structure as original
 	dmaBatchLength := d.batchLength * 4 // Use 4x larger batches for DMA
 	maxBatchLength := d.maxTransferSize / 2 // 2 bytes per pixel
@@ -535,24 +577,14 @@ func (d *Device) fillRectangleWithImageDMA(width, height int16, fb *image.RGBA)
 			currentBatch = totalPixels
 		}
 		
-		// For each batch, iterate over currentBatch pixels.
-		for i := int32(0); i < currentBatch; i++ {
-			if offset+i < int32(width)*int32(height) {
-				// Compute the row and column for the current pixel.
-				row := int((offset + i) / int32(width))
-				col := int((offset + i) % int32(width))
-				// Get the pixel color from the image.
-				pixel := fb.RGBAAt(col, row)
-				// Convert to RGB565.
-				c565 := RGBATo565BGR(pixel)
-				// Store the high and low bytes.
-				dmaBuffer[i*2] = uint8(c565 >> 8)
-				dmaBuffer[i*2+1] = uint8(c565)
-			}
-		}
+		// Convert the batch's pixels, row by row.
+		image565(fb, int(offset), int(currentBatch), dmaBuffer)
 		
 		// Transmit the batch.
-		d.TxWithCS(dmaBuffer[:currentBatch*2], false, false)
+		if err := d.transport.WritePixels(dmaBuffer[:currentBatch*2], 1); err != nil {
+			d.EndTransaction()
+			return err
+		}
 		totalPixels -= currentBatch
 		offset += currentBatch
 	}
@@ -563,7 +595,7 @@ func (d *Device) fillRectangleWithImageDMA(width, height int16, fb *image.RGBA)
 }
 
 // fillRectangleWithImageOriginal uses the original transfer logic (no DMA)
-func (d *Device) fillRectangleWithImageOriginal(width, height int16, fb *image.RGBA) error {
+func (d *Device) fillRectangleWithImageOriginal(width, height int16, fb image.Image) error {
 	// Start CS transaction for the entire transfer
 	d.BeginTransaction()
 
@@ -573,26 +605,17 @@ func (d *Device) fillRectangleWithImageOriginal(width, height int16, fb *image.R
 
 	// Process pixels in batches.
 	for totalPixels > 0 {
-		// For each batch, iterate over d.batchLength pixels (or the remaining pixels).
-		for i := int32(0); i < d.batchLength; i++ {
-			if offset+i < int32(width)*int32(height) {
-				// Compute the row and column for the current pixel.
-				row := int((offset + i) / int32(width))
-				col := int((offset + i) % int32(width))
-				// Get the pixel color from the image.
-				pixel := fb.RGBAAt(col, row)
-				// Convert to RGB565.
-				c565 := RGBATo565BGR(pixel)
-				// Store the high and low bytes.
-				d.buffer[i*2] = uint8(c565 >> 8)
-				d.buffer[i*2+1] = uint8(c565)
-			}
+		// Convert the batch's pixels, row by row.
+		n := totalPixels
+		if n > d.batchLength {
+			n = d.batchLength
 		}
+		image565(fb, int(offset), int(n), d.buffer)
+
 		// Transmit the batch.
-		if totalPixels >= d.batchLength {
-			d.TxWithCS(d.buffer, false, false)
-		} else {
-			d.TxWithCS(d.buffer[:totalPixels*2], false, false)
+		if err := d.transport.WritePixels(d.buffer[:n*2], 1); err != nil {
+			d.EndTransaction()
+			return err
 		}
 		totalPixels -= d.batchLength
 		offset += d.batchLength
@@ -657,6 +680,12 @@ func (d *Device) SetRotation(rotation Rotation) {
 	if d.isBGR {
 		madctl |= MADCTL_BGR
 	}
+	d.rotation = rotation % 4
+	// The offsets and axes changed, so the cached window no longer applies
+	d.window.invalidate()
+	if d.shadow != nil {
+		d.shadow.reset(d)
+	}
 	d.Command(MADCTL)
 	d.Data(madctl)
 }
@@ -678,12 +707,16 @@ func (d *Device) Tx(data []byte, isCommand bool) {
 
 // TxWithCS sends data to the display (CS parameter ignored for performance)
 func (d *Device) TxWithCS(data []byte, isCommand bool, toggleCS bool) {
-	if isCommand {
-		d.dcPin.Out(gpio.Low)
-	} else {
-		d.dcPin.Out(gpio.High)
+	if !isCommand {
+		d.transport.WriteData(data)
+		return
+	}
+	// A raw command may change the address window behind the cache
+	d.window.invalidate()
+	for _, c := range data {
+		d.transport.WriteCommand(c, nil)
 	}
-	d.bus.Tx(data, nil)
+	d.transport.Flush()
 }
 
 // BeginTransaction starts a CS transaction (no-op for performance)
@@ -698,21 +731,24 @@ func (d *Device) EndTransaction() {
 
 // Rx reads data from the display
 func (d *Device) Rx(command uint8, data []byte) {
-	d.dcPin.Out(gpio.Low)
-	sendCommand(d.bus, command)
+	d.transport.Read(command, data)
+}
 
-	d.dcPin.Out(gpio.High)
-	for i := range data {
-		data[i], _ = sendCommand(d.bus, 0xFF)
+// WriteRegister sends a command with its parameters and waits until they
+// went out, for registers the driver has no method for.
+func (d *Device) WriteRegister(command uint8, params ...byte) error {
+	// The command may change the address window behind the cache
+	d.window.invalidate()
+	if err := d.transport.WriteCommand(command, params); err != nil {
+		return err
 	}
+	return d.transport.Flush()
 }
 
-func sendCommand(bus spi.Conn, command byte) (byte, error) {
-	err := bus.Tx([]byte{command}, nil)
-	if err != nil {
-		return 0, err
-	}
-	return 0, nil
+// ReadRegister sends a command and reads len(data) bytes of its reply. Like
+// Rx, but reporting errors.
+func (d *Device) ReadRegister(command uint8, data []byte) error {
+	return d.transport.Read(command, data)
 }
 
 // Size returns the current size of the display.
@@ -732,6 +768,20 @@ func (d *Device) EnableBacklight(enable bool) {
 	}
 }
 
+// Sleep puts the panel to sleep, turning the display off, or wakes it up.
+// The memory keeps its contents while sleeping.
+func (d *Device) Sleep(sleep bool) {
+	if sleep {
+		d.Command(DISPOFF)
+		d.Command(SLPIN)
+		time.Sleep(5 * time.Millisecond)
+		return
+	}
+	d.Command(SLPOUT)
+	time.Sleep(120 * time.Millisecond) // Sleep out needs 120 ms to settle
+	d.Command(DISPON)
+}
+
 // InvertColors inverts the colors of the screen
 func (d *Device) InvertColors(invert bool) {
 	if invert {
@@ -748,6 +798,7 @@ func (d *Device) IsBGR(bgr bool) {
 
 // SetScrollArea sets an area to scroll with fixed top and bottom parts of the display.
 func (d *Device) SetScrollArea(topFixedArea, bottomFixedArea int16) {
+	d.window.invalidate()
 	d.Command(VSCRDEF)
 	d.Tx([]uint8{
 		uint8(topFixedArea >> 8), uint8(topFixedArea),
@@ -758,12 +809,14 @@ func (d *Device) SetScrollArea(topFixedArea, bottomFixedArea int16) {
 
 // SetScroll sets the vertical scroll address of the display.
 func (d *Device) SetScroll(line int16) {
+	d.window.invalidate()
 	d.Command(VSCRSADD)
 	d.Tx([]uint8{uint8(line >> 8), uint8(line)}, false)
 }
 
 // StopScroll returns the display to its normal state.
 func (d *Device) StopScroll() {
+	d.window.invalidate()
 	d.Command(NORON)
 }
 
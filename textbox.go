@@ -0,0 +1,223 @@
+package gc9307
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+// Align is the horizontal alignment of the lines in a TextBox.
+type Align uint8
+
+// Horizontal alignments
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// VAlign is the vertical alignment of the text in a TextBox.
+type VAlign uint8
+
+// Vertical alignments
+const (
+	AlignTop VAlign = iota
+	AlignMiddle
+	AlignBottom
+)
+
+// TextBox describes how text is laid out in a rectangle.
+type TextBox struct {
+	Font        Font
+	FG, BG      color.RGBA // BG with A == 0 leaves the background untouched
+	Align       Align
+	VAlign      VAlign
+	LineSpacing int // Extra pixels between lines, may be negative
+	// Wrap breaks lines at spaces, and between CJK characters, to fit the
+	// width. A word wider than the box is broken anywhere. Without Wrap,
+	// lines only end at '\n'.
+	Wrap bool
+	// Ellipsis ends a line that was cut short with "…" ("..." if the font
+	// has no U+2026): lines wider than the box without Wrap, and the last
+	// line when more lines follow than fit in the box. Otherwise such lines
+	// are clipped.
+	Ellipsis bool
+}
+
+// TextLine is a line of text placed by TextBox.Layout.
+type TextLine struct {
+	Text  string
+	X, Y  int // Top left corner
+	Width int
+}
+
+// Layout splits s into lines placed inside r. It returns the lines that fit
+// and the rectangle they cover, not clipped to r.
+func (b *TextBox) Layout(r image.Rectangle, s string) (lines []TextLine, extent image.Rectangle) {
+	ascent, descent := b.Font.Metrics()
+	height := ascent + descent
+	pitch := height + b.LineSpacing
+	if r.Dx() <= 0 || r.Dy() < height || pitch <= 0 {
+		return nil, image.Rectangle{}
+	}
+
+	var texts []string
+	for _, para := range strings.Split(s, "\n") {
+		para = strings.TrimRight(para, "\r")
+		if b.Wrap {
+			texts = append(texts, wrapText(para, r.Dx(), b.Font)...)
+		} else {
+			texts = append(texts, para)
+		}
+	}
+
+	if fit := (r.Dy()-height)/pitch + 1; len(texts) > fit {
+		texts = texts[:fit]
+		if b.Ellipsis {
+			texts[fit-1] = ellipsize(texts[fit-1], r.Dx(), b.Font, true)
+		}
+	}
+	if b.Ellipsis {
+		for i, t := range texts {
+			texts[i] = ellipsize(t, r.Dx(), b.Font, false)
+		}
+	}
+
+	total := len(texts)*pitch - b.LineSpacing
+	y := r.Min.Y
+	switch b.VAlign {
+	case AlignMiddle:
+		y += (r.Dy() - total) / 2
+	case AlignBottom:
+		y = r.Max.Y - total
+	}
+	for _, t := range texts {
+		w, _ := MeasureText(t, b.Font)
+		x := r.Min.X
+		switch b.Align {
+		case AlignCenter:
+			x += (r.Dx() - w) / 2
+		case AlignRight:
+			x = r.Max.X - w
+		}
+		lines = append(lines, TextLine{Text: t, X: x, Y: y, Width: w})
+		extent = extent.Union(image.Rect(x, y, x+w, y+height))
+		y += pitch
+	}
+	return lines, extent
+}
+
+// DrawTextBox lays out s in r, clipped to the display as returned by Size,
+// and draws it. It returns the rectangle covered by the drawn lines.
+func (d *Device) DrawTextBox(r image.Rectangle, s string, b *TextBox) (image.Rectangle, error) {
+	r = r.Intersect(d.bounds())
+	lines, extent := b.Layout(r, s)
+	for _, l := range lines {
+		if err := d.drawBitmap(l.X, l.Y, renderText(l.Text, b.Font), b.FG, b.BG, r); err != nil {
+			return extent.Intersect(r), err
+		}
+	}
+	return extent.Intersect(r), nil
+}
+
+// wrapText splits a paragraph into lines no wider than width
+func wrapText(s string, width int, font Font) []string {
+	rs := []rune(s)
+	if len(rs) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	for len(rs) > 0 {
+		end, next := fitLine(rs, width, font)
+		lines = append(lines, strings.TrimRight(string(rs[:end]), " "))
+		rs = rs[next:]
+	}
+	return lines
+}
+
+// fitLine returns where the first line of rs ends and where the next one
+// starts, skipping the spaces at the break
+func fitLine(rs []rune, width int, font Font) (end, next int) {
+	w := 0
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r == ' ' {
+			// Spaces may hang past the edge, the line can end before them
+			j := i
+			for j < len(rs) && rs[j] == ' ' {
+				w += runeAdvance(' ', font)
+				j++
+			}
+			if i > 0 {
+				end, next = i, j
+			}
+			if j == len(rs) {
+				return i, j
+			}
+			i = j - 1
+			continue
+		}
+		if i > 0 && rs[i-1] != ' ' && breakBetween(rs[i-1], r) {
+			end, next = i, i
+		}
+		w += runeAdvance(r, font)
+		if w > width {
+			switch {
+			case end > 0:
+				return end, next
+			case i == 0:
+				// A single character wider than the box
+				return 1, 1
+			}
+			return i, i
+		}
+	}
+	return len(rs), len(rs)
+}
+
+// runeAdvance returns the advance DrawText uses for r
+func runeAdvance(r rune, font Font) int {
+	if isControl(r) {
+		return 0
+	}
+	if g, ok := lookupGlyph(font, r); ok {
+		return g.Advance
+	}
+	return 0
+}
+
+// breakBetween reports whether a line may break between a and b without a
+// space, which is the case around CJK characters except before closing and
+// after opening punctuation
+func breakBetween(a, b rune) bool {
+	return (isCJK(a) || isCJK(b)) &&
+		!strings.ContainsRune("，。、；：！？）」』】〉》〕,.;:!?)]}", b) &&
+		!strings.ContainsRune("（「『【〈《〔([{", a)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK symbols and punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) // Halfwidth and fullwidth forms
+}
+
+// ellipsize shortens s so that it fits in width with an ellipsis appended.
+// Without force, s is returned unchanged if it already fits.
+func ellipsize(s string, width int, font Font, force bool) string {
+	if w, _ := MeasureText(s, font); !force && w <= width {
+		return s
+	}
+	e := "…"
+	if _, ok := font.Glyph('…'); !ok {
+		e = "..."
+	}
+	rs := []rune(s)
+	for n := len(rs); n >= 0; n-- {
+		t := strings.TrimRight(string(rs[:n]), " ") + e
+		if w, _ := MeasureText(t, font); w <= width {
+			return t
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package gc9307
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// Font6x11 lines are 11 pixels high and its characters 6 pixels wide; the
+// CJK characters, which it lacks, are drawn as '?' of the same width.
+func TestTextBoxLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		box  TextBox
+		w, h int // Size of the box, in characters and lines
+		s    string
+		want []string
+	}{
+		{"Wrap", TextBox{Wrap: true}, 6, 3, "hello world", []string{"hello", "world"}},
+		{"WrapSpaces", TextBox{Wrap: true}, 6, 3, "abc    def  ", []string{"abc", "def"}},
+		{"WrapLongWord", TextBox{Wrap: true}, 6, 3, "abcdefghij", []string{"abcdef", "ghij"}},
+		{"WrapAfterLongWord", TextBox{Wrap: true}, 4, 3, "abcdef gh", []string{"abcd", "ef", "gh"}},
+		{"Paragraphs", TextBox{Wrap: true}, 6, 4, "a\r\n\nb", []string{"a", "", "b"}},
+		{"CJK", TextBox{Wrap: true}, 6, 3, "日本語日本語日本", []string{"日本語日本語", "日本"}},
+		// No break before closing punctuation
+		{"CJKPunctuation", TextBox{Wrap: true}, 6, 3, "日本語日本語。", []string{"日本語日本", "語。"}},
+		{"CJKAfterLatin", TextBox{Wrap: true}, 5, 3, "abc日本語", []string{"abc日本", "語"}},
+		{"NoWrap", TextBox{}, 6, 3, "hello world", []string{"hello world"}},
+		{"NoWrapEllipsis", TextBox{Ellipsis: true}, 6, 3, "hello world\nok", []string{"hel...", "ok"}},
+		{"TooManyLines", TextBox{}, 6, 2, "one\ntwo\nthree", []string{"one", "two"}},
+		// The last line that fits is marked as cut short, even if it fits
+		{"TooManyLinesEllipsis", TextBox{Ellipsis: true}, 6, 2, "one\ntwo\nthree", []string{"one", "two..."}},
+		{"WrapEllipsis", TextBox{Wrap: true, Ellipsis: true}, 6, 1, "hello world", []string{"hel..."}},
+		{"EllipsisTrimsSpaces", TextBox{Ellipsis: true}, 6, 1, "ab   cdefgh", []string{"ab..."}},
+		{"NarrowEllipsis", TextBox{Ellipsis: true}, 2, 1, "hello", []string{""}},
+		{"TooLow", TextBox{}, 6, 0, "hello", nil},
+		{"NoLineHeight", TextBox{LineSpacing: -11}, 6, 2, "hello", nil},
+	} {
+		tc.box.Font = Font6x11
+		r := image.Rect(0, 0, tc.w*6, tc.h*11)
+		lines, _ := tc.box.Layout(r, tc.s)
+		var got []string
+		for _, l := range lines {
+			got = append(got, l.Text)
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+			t.Errorf("%s: lines %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTextBoxAlign(t *testing.T) {
+	r := image.Rect(10, 20, 46, 53) // Six characters, three lines
+	for _, tc := range []struct {
+		name       string
+		align      Align
+		valign     VAlign
+		spacing    int
+		xs         []int
+		y          int
+		wantExtent image.Rectangle
+	}{
+		{"TopLeft", AlignLeft, AlignTop, 0, []int{10, 10}, 20, image.Rect(10, 20, 34, 42)},
+		{"Center", AlignCenter, AlignMiddle, 0, []int{16, 19}, 25, image.Rect(16, 25, 40, 47)},
+		{"BottomRight", AlignRight, AlignBottom, 0, []int{22, 28}, 31, image.Rect(22, 31, 46, 53)},
+		{"Spacing", AlignLeft, AlignBottom, 2, []int{10, 10}, 29, image.Rect(10, 29, 34, 53)},
+	} {
+		b := TextBox{Font: Font6x11, Align: tc.align, VAlign: tc.valign, LineSpacing: tc.spacing}
+		lines, extent := b.Layout(r, "abcd\nefg")
+		if len(lines) != 2 {
+			t.Fatalf("%s: %d lines", tc.name, len(lines))
+		}
+		for i, l := range lines {
+			if y := tc.y + i*(11+tc.spacing); l.X != tc.xs[i] || l.Y != y {
+				t.Errorf("%s: line %d at (%d, %d), want (%d, %d)", tc.name, i, l.X, l.Y, tc.xs[i], y)
+			}
+		}
+		if extent != tc.wantExtent {
+			t.Errorf("%s: extent %v, want %v", tc.name, extent, tc.wantExtent)
+		}
+	}
+}
+
+// DrawTextBox clips the lines to the box and the display.
+func TestDrawTextBox(t *testing.T) {
+	d, r := recorderDevice(t)
+	b := TextBox{Font: Font6x11, FG: color.RGBA{255, 255, 255, 255}, BG: color.RGBA{0, 0, 0, 255}}
+	extent, err := d.DrawTextBox(image.Rect(-3, 0, 9, 11), "abcd", &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extent != image.Rect(0, 0, 9, 11) {
+		t.Errorf("extent %v", extent)
+	}
+	if n := len(sentPixels(r)); n != 9*11*2 {
+		t.Errorf("sent %d bytes of pixels, want %d", n, 9*11*2)
+	}
+}
@@ -0,0 +1,211 @@
+package gc9307
+
+import (
+	"periph.io/x/conn/v3"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/spi"
+)
+
+// In 3-wire mode the panel has no DC input. Every byte on the wire is
+// prefixed by a D/C bit (0 for command, 1 for data), forming 9-bit words.
+// The driver either packs those words into a plain 8-bit stream or, when
+// the controller supports it, sends them as native 9-bit words.
+//
+// A packed stream that does not end on a byte boundary is padded with zero
+// bits. The panel drops the incomplete word when CS is released, so 3-wire
+// mode needs the CS line to be driven by the SPI controller.
+
+// dcBit returns the 3-wire D/C bit for a DC level
+func dcBit(dc gpio.Level) uint32 {
+	if dc == gpio.High {
+		return 1
+	}
+	return 0
+}
+
+// pack9 appends the 9-bit words for data, MSB first, to out. acc and nbits
+// carry the bits not yet written between calls.
+func pack9(out []byte, data []byte, dc gpio.Level, acc uint32, nbits uint) ([]byte, uint32, uint) {
+	prefix := dcBit(dc) << 8
+	for _, b := range data {
+		acc = acc<<9 | prefix | uint32(b)
+		nbits += 9
+		for nbits >= 8 {
+			nbits -= 8
+			out = append(out, uint8(acc>>nbits))
+		}
+		acc &= 1<<nbits - 1
+	}
+	return out, acc, nbits
+}
+
+// words9 appends data as 9-bit words in the 16-bit little-endian container
+// spidev uses for word sizes above 8 bits.
+func words9(out []byte, data []byte, dc gpio.Level) []byte {
+	prefix := uint8(dcBit(dc))
+	for _, b := range data {
+		out = append(out, b, prefix)
+	}
+	return out
+}
+
+// ThreeWire is the Transport for the 3-wire serial interface, where the
+// panel has no DC input and each byte on the wire carries its own D/C bit.
+//
+// Everything is queued and encoded into a single transfer on flush, so
+// commands always go out together with the data following them.
+type ThreeWire struct {
+	bus          spi.Conn
+	nineBitWords bool
+	nineBitBus   bool // Asked for by NewThreeWire, kept over Configure
+	queue        packetQueue
+	wire         []uint8 // Encoded transfer
+	scratch      [1]uint8
+}
+
+// NewThreeWire returns a 3-wire SPI transport. With nineBitWords the SPI
+// connection must be configured for 9 bits per word, otherwise the 9-bit
+// words are packed into an 8-bit stream.
+func NewThreeWire(bus spi.Conn, nineBitWords bool) *ThreeWire {
+	t := &ThreeWire{
+		bus:          bus,
+		nineBitWords: nineBitWords,
+		nineBitBus:   nineBitWords,
+	}
+	t.queue.limit = t.rawLimit(busLimit(bus, 0))
+	return t
+}
+
+// configure follows Config.Use9BitWords, on top of what NewThreeWire was
+// asked for, so configuring again without it goes back to packed words.
+func (t *ThreeWire) configure(cfg transportConfig) {
+	t.nineBitWords = t.nineBitBus || cfg.nineBitWords
+	t.queue.limit = t.rawLimit(busLimit(t.bus, cfg.maxTransferSize))
+}
+
+// rawLimit converts a wire size limit into the byte budget of the queue,
+// since every byte grows on the wire in 3-wire mode.
+func (t *ThreeWire) rawLimit(limit int32) int32 {
+	if t.nineBitWords {
+		return limit / 2
+	}
+	return limit * 8 / 9
+}
+
+// add queues w, flushing the queue first when needed
+func (t *ThreeWire) add(w []byte, dc gpio.Level, copied bool) error {
+	if t.queue.full(len(w), dc, true) {
+		if err := t.Flush(); err != nil {
+			return err
+		}
+	}
+	if copied {
+		t.queue.addCopy(w, dc)
+	} else {
+		t.queue.add(w, dc)
+	}
+	return nil
+}
+
+// WriteCommand implements Transport.
+func (t *ThreeWire) WriteCommand(cmd uint8, params []byte) error {
+	t.scratch[0] = cmd
+	if err := t.add(t.scratch[:], gpio.Low, true); err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return t.add(params, gpio.High, true)
+}
+
+// WriteData implements Transport.
+func (t *ThreeWire) WriteData(data []byte) error {
+	return t.WritePixels(data, 1)
+}
+
+// WritePixels implements Transport.
+func (t *ThreeWire) WritePixels(pix []byte, count int) error {
+	add := func(w []byte) error { return t.add(w, gpio.High, false) }
+	for i := 0; i < count; i++ {
+		if err := t.queue.split(pix, add); err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
+
+// Flush implements Transport.
+func (t *ThreeWire) Flush() error {
+	if len(t.queue.packets) == 0 {
+		return nil
+	}
+	out := t.wire[:0]
+	if t.nineBitWords {
+		for i, p := range t.queue.packets {
+			out = words9(out, p.W, t.queue.dc[i])
+		}
+	} else {
+		acc, nbits := uint32(0), uint(0)
+		for i, p := range t.queue.packets {
+			out, acc, nbits = pack9(out, p.W, t.queue.dc[i], acc, nbits)
+		}
+		if nbits > 0 {
+			out = append(out, uint8(acc<<(8-nbits)))
+		}
+	}
+	t.wire = out
+	t.queue.reset()
+
+	p := spi.Packet{W: out}
+	if t.nineBitWords {
+		p.BitsPerWord = 9
+	}
+	return t.bus.TxPackets([]spi.Packet{p})
+}
+
+// Read implements Transport.
+//
+// Reads longer than one byte start with a dummy clock cycle, as in the
+// datasheet's 3-line serial read timing. On a half duplex connection the
+// command, dummy cycle and response are separate packets with their own
+// word sizes. Otherwise the read is a single full-duplex 8-bit transfer: the
+// first 9 bits clock out the command, the response follows on MISO.
+func (t *ThreeWire) Read(cmd uint8, data []byte) error {
+	if err := t.Flush(); err != nil {
+		return err
+	}
+	skip := uint(9)
+	if len(data) > 1 {
+		skip++
+	}
+
+	if t.bus.Duplex() == conn.Half {
+		p := []spi.Packet{{W: []byte{cmd, 0}, BitsPerWord: 9, KeepCS: true}}
+		if skip > 9 {
+			p = append(p, spi.Packet{R: make([]byte, 1), BitsPerWord: 1, KeepCS: true})
+		}
+		p = append(p, spi.Packet{R: data, BitsPerWord: 8})
+		return t.bus.TxPackets(p)
+	}
+
+	n := (int(skip) + len(data)*8 + 7) / 8
+	w, acc, nbits := pack9(make([]byte, 0, n), []byte{cmd}, gpio.Low, 0, 0)
+	w = append(w, uint8(acc<<(8-nbits)))
+	w = w[:n]
+	r := make([]byte, n+1) // one spare byte to simplify the bit extraction
+
+	if err := t.bus.TxPackets([]spi.Packet{{W: w, R: r[:n], BitsPerWord: 8}}); err != nil {
+		return err
+	}
+
+	// Extract the response bits following the command
+	for i := range data {
+		bit := skip + uint(i)*8
+		v := uint16(r[bit/8])<<8 | uint16(r[bit/8+1])
+		data[i] = uint8(v >> (8 - bit%8))
+	}
+	return nil
+}
+
+var _ Transport = &ThreeWire{}
@@ -0,0 +1,210 @@
+package gc9307
+
+import (
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/spi"
+)
+
+// Transport carries commands, parameters and pixel data to the panel. It
+// hides how the D/C information travels, so the drawing code works the same
+// with a DC pin, with 3-wire 9-bit words, or without any hardware at all.
+//
+// Transports may buffer commands until the next WriteData, WritePixels or
+// Read call, or until Flush. Buffers passed to the write methods can be
+// reused as soon as the call returns.
+type Transport interface {
+	// WriteCommand sends a command byte followed by its parameters, if any.
+	WriteCommand(cmd uint8, params []byte) error
+	// WriteData sends bytes in data mode.
+	WriteData(data []byte) error
+	// WritePixels sends count copies of pix as pixel data, after RAMWR.
+	WritePixels(pix []byte, count int) error
+	// Read sends cmd and fills data with the bytes read back.
+	Read(cmd uint8, data []byte) error
+	// Flush sends any buffered command.
+	Flush() error
+}
+
+// transportConfig holds the Config fields that affect the wire protocol
+type transportConfig struct {
+	usePackets      bool
+	nineBitWords    bool
+	maxTransferSize int32
+	csPin           gpio.PinOut // Panel CS driven by the transport, or nil
+}
+
+// configurable is implemented by transports that follow Device.Configure
+type configurable interface {
+	configure(cfg transportConfig)
+}
+
+// FourWire is the Transport for the usual 4-wire SPI wiring, where a separate
+// DC pin selects between commands and data.
+//
+// Pixel batches are sent with spi.Conn.TxPackets, as many per call as the
+// bus accepts. Commands and their parameters go out as separate transfers,
+// because the DC pin can only change between calls; only when the bus
+// implements DCPacketConn are they queued and sent in the same call as the
+// following data.
+//
+// The controller releases its CS after every call, which ends a read before
+// the reply: 4-wire reads need the panel's CS on a GPIO, given to New and
+// enabled with Config.UseCS. The transport then keeps it low and pulses it
+// high after each read.
+type FourWire struct {
+	bus        spi.Conn
+	dcPin      gpio.PinOut
+	csPin      gpio.PinOut  // nil unless Config.UseCS
+	dcConn     DCPacketConn // Set when the bus drives DC between packets
+	usePackets bool
+	queue      packetQueue
+	scratch    [1]uint8
+}
+
+// NewFourWire returns a 4-wire SPI transport. The SPI wire must already be
+// configured.
+func NewFourWire(bus spi.Conn, dcPin gpio.PinOut) *FourWire {
+	dcConn, _ := bus.(DCPacketConn)
+	t := &FourWire{
+		bus:        bus,
+		dcPin:      dcPin,
+		dcConn:     dcConn,
+		usePackets: true,
+	}
+	t.queue.limit = busLimit(bus, 0)
+	return t
+}
+
+func (t *FourWire) configure(cfg transportConfig) {
+	t.usePackets = cfg.usePackets
+	t.queue.limit = busLimit(t.bus, cfg.maxTransferSize)
+	t.csPin = cfg.csPin
+	if t.csPin != nil {
+		t.csPin.Out(gpio.Low) // Selected, except to end reads
+	}
+}
+
+// add queues w, flushing the queue first when needed
+func (t *FourWire) add(w []byte, dc gpio.Level, copied bool) error {
+	if t.queue.full(len(w), dc, t.dcConn != nil) {
+		if err := t.Flush(); err != nil {
+			return err
+		}
+	}
+	if copied {
+		t.queue.addCopy(w, dc)
+	} else {
+		t.queue.add(w, dc)
+	}
+	return nil
+}
+
+// WriteCommand implements Transport.
+func (t *FourWire) WriteCommand(cmd uint8, params []byte) error {
+	t.scratch[0] = cmd
+
+	// Queue the command so it goes out together with the following data
+	if t.usePackets && t.dcConn != nil {
+		if err := t.add(t.scratch[:], gpio.Low, true); err != nil {
+			return err
+		}
+		if len(params) == 0 {
+			return nil
+		}
+		return t.add(params, gpio.High, true)
+	}
+
+	if err := t.Flush(); err != nil {
+		return err
+	}
+	t.dcPin.Out(gpio.Low) // Command mode
+	if err := t.bus.Tx(t.scratch[:], nil); err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	t.dcPin.Out(gpio.High) // Data mode
+	return t.bus.Tx(params, nil)
+}
+
+// WriteData implements Transport.
+func (t *FourWire) WriteData(data []byte) error {
+	return t.writeData(data, 1)
+}
+
+// WritePixels implements Transport.
+func (t *FourWire) WritePixels(pix []byte, count int) error {
+	return t.writeData(pix, count)
+}
+
+func (t *FourWire) writeData(data []byte, count int) error {
+	if !t.usePackets {
+		if err := t.Flush(); err != nil {
+			return err
+		}
+		t.dcPin.Out(gpio.High)
+		for i := 0; i < count; i++ {
+			err := t.queue.split(data, func(w []byte) error { return t.bus.Tx(w, nil) })
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// data is on the wire before returning, so it can be queued as is
+	add := func(w []byte) error { return t.add(w, gpio.High, false) }
+	for i := 0; i < count; i++ {
+		if err := t.queue.split(data, add); err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
+
+// Read implements Transport. Without a CS pin, the panel sees the reply
+// phase as a new transfer and most do not answer it.
+func (t *FourWire) Read(cmd uint8, data []byte) error {
+	if err := t.Flush(); err != nil {
+		return err
+	}
+	if t.csPin != nil {
+		// CS stays low across the command and the reply; the rising edge
+		// ends the read
+		defer func() {
+			t.csPin.Out(gpio.High)
+			t.csPin.Out(gpio.Low)
+		}()
+	}
+	t.scratch[0] = cmd
+	t.dcPin.Out(gpio.Low)
+	if err := t.bus.Tx(t.scratch[:], nil); err != nil {
+		return err
+	}
+
+	t.dcPin.Out(gpio.High)
+	w := make([]byte, len(data))
+	for i := range w {
+		w[i] = 0xFF
+	}
+	return t.bus.Tx(w, data)
+}
+
+// Flush implements Transport.
+func (t *FourWire) Flush() error {
+	if len(t.queue.packets) == 0 {
+		return nil
+	}
+	var err error
+	if t.dcConn != nil {
+		err = t.dcConn.TxPacketsDC(t.queue.last(), t.queue.dc)
+	} else {
+		t.dcPin.Out(t.queue.dc[0])
+		err = t.bus.TxPackets(t.queue.last())
+	}
+	t.queue.reset()
+	return err
+}
+
+var _ Transport = &FourWire{}
@@ -0,0 +1,84 @@
+package gc9307
+
+import (
+	"testing"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/spi"
+)
+
+// logBus is a countingBus that also logs its transfers into a pin log, as
+// TX events.
+type logBus struct {
+	countingBus
+	log *[]event
+}
+
+func (b *logBus) Tx(w, r []byte) error {
+	return b.TxPackets([]spi.Packet{{W: w, R: r}})
+}
+
+func (b *logBus) TxPackets(p []spi.Packet) error {
+	*b.log = append(*b.log, event{pin: "TX", what: 'o'})
+	return b.countingBus.TxPackets(p)
+}
+
+// With UseCS the CS pin stays low across the command and the reply of a
+// read, and the read ends on its rising edge.
+func TestFourWireReadCS(t *testing.T) {
+	for _, tc := range []struct {
+		useCS bool
+		want  string
+	}{
+		{false, "TX0 TX0"},
+		{true, "TX0 TX0 CS1 CS0"},
+	} {
+		w := newFakeWires()
+		w.cs.level = gpio.High
+		bus := &logBus{log: &w.log}
+		d := New(bus, gpio.INVALID, gpio.INVALID, w.cs, gpio.INVALID)
+		cfg := pcatConfig
+		cfg.KeepContents = true
+		cfg.UseCS = tc.useCS
+		configureTest(t, &d, cfg, true)
+		if tc.useCS && w.cs.level != gpio.Low {
+			t.Error("CS not selected after Configure")
+		}
+
+		w.log = nil
+		d.Rx(RDDID, make([]byte, 4))
+		if got := w.trace(); got != tc.want {
+			t.Errorf("UseCS %t: got %s, want %s", tc.useCS, got, tc.want)
+		}
+	}
+}
+
+// Configure switches 9-bit words on and off again, unless NewThreeWire asked
+// for them.
+func TestThreeWireReconfigure(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		nine  bool   // Passed to NewThreeWire
+		steps []bool // Use9BitWords of each configure
+		want  []int  // Bytes on the wire for 4 data bytes after each step
+	}{
+		{"Packed", false, []bool{true, false}, []int{8, 5}},
+		{"NineBitBus", true, []bool{false, true}, []int{8, 8}},
+	} {
+		bus := &countingBus{}
+		tr := NewThreeWire(bus, tc.nine)
+		for i, nine := range tc.steps {
+			tr.configure(transportConfig{usePackets: true, nineBitWords: nine})
+			bus.reset()
+			if err := tr.WriteData([]byte{1, 2, 3, 4}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tr.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if bus.bytes != tc.want[i] {
+				t.Errorf("%s, step %d: %d bytes, want %d", tc.name, i, bus.bytes, tc.want[i])
+			}
+		}
+	}
+}
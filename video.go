@@ -0,0 +1,257 @@
+package gc9307
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VideoOptions configures PlayRGB565 and PlayY4M.
+type VideoOptions struct {
+	X, Y int16 // Position of the top left corner; the video is clipped to the display
+	// FrameRate in frames per second. Required for raw streams, overrides
+	// the rate of Y4M streams when set.
+	FrameRate float64
+	// VSync waits for the display's vertical blanking before each frame to
+	// avoid tearing. It needs wiring that can read the scan line back.
+	VSync bool
+}
+
+// VideoStats reports how playback went.
+type VideoStats struct {
+	Frames  int // Frames shown
+	Dropped int // Frames skipped because the display could not keep up
+}
+
+// RawFormat is the pixel layout of a raw RGB565 stream.
+type RawFormat uint8
+
+// Raw RGB565 layouts
+const (
+	RawRGB565   RawFormat = iota // Big endian RGB565 (ffmpeg -pix_fmt rgb565be)
+	RawRGB565LE                  // Little endian RGB565 (ffmpeg -pix_fmt rgb565le)
+	RawNative                    // The panel's own byte order, as RGBATo565BGR, sent unconverted
+)
+
+// PlayRGB565 plays a stream of raw width x height RGB565 frames, one after
+// the other without any header, at opts.FrameRate. It returns when the
+// stream ends or ctx is done.
+//
+// Frames are paced against the start time. A frame that is already more
+// than one frame interval late is read but not shown, and counted in
+// VideoStats.Dropped.
+func (d *Device) PlayRGB565(ctx context.Context, r io.Reader, width, height int, format RawFormat, opts VideoOptions) (VideoStats, error) {
+	if width <= 0 || height <= 0 {
+		return VideoStats{}, errors.New("video: invalid frame size")
+	}
+	buf := make([]byte, width*height*2)
+	next := func() error {
+		_, err := io.ReadFull(r, buf)
+		return err
+	}
+	convert := func(x, y, n int, dst []byte) {
+		src := buf[(y*width+x)*2 : (y*width+x+n)*2]
+		if format == RawNative {
+			copy(dst, src)
+			return
+		}
+		for i := 0; i < n; i++ {
+			var c uint16
+			if format == RawRGB565LE {
+				c = uint16(src[i*2]) | uint16(src[i*2+1])<<8
+			} else {
+				c = uint16(src[i*2])<<8 | uint16(src[i*2+1])
+			}
+			// The panel expects red and blue swapped
+			put565(dst[i*2:], c&0x07E0|c>>11|c<<11)
+		}
+	}
+	return d.playVideo(ctx, opts, opts.FrameRate, width, height, next, convert)
+}
+
+// PlayY4M plays a YUV4MPEG2 stream, e.g. from
+// "ffmpeg -i clip.mp4 -vf scale=172:-2 -pix_fmt yuv420p -f yuv4mpegpipe -",
+// converting it to RGB565 on the fly. 4:2:0, 4:2:2, 4:4:4 and mono 8 bit
+// streams are supported, in limited or full (XCOLORRANGE=FULL) range.
+// Pacing and dropping work as for PlayRGB565.
+func (d *Device) PlayY4M(ctx context.Context, r io.Reader, opts VideoOptions) (VideoStats, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return VideoStats{}, fmt.Errorf("y4m: reading header: %w", err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "YUV4MPEG2" {
+		return VideoStats{}, errors.New("y4m: not a YUV4MPEG2 stream")
+	}
+
+	var width, height int
+	var fps float64
+	chroma, fullRange := "420", false
+	for _, f := range fields[1:] {
+		v := f[1:]
+		switch f[0] {
+		case 'W':
+			width, _ = strconv.Atoi(v)
+		case 'H':
+			height, _ = strconv.Atoi(v)
+		case 'F':
+			if num, den, ok := strings.Cut(v, ":"); ok {
+				nv, _ := strconv.ParseFloat(num, 64)
+				dv, _ := strconv.ParseFloat(den, 64)
+				if dv > 0 {
+					fps = nv / dv
+				}
+			}
+		case 'C':
+			chroma = v
+		case 'X':
+			fullRange = fullRange || v == "COLORRANGE=FULL"
+		}
+	}
+	if width <= 0 || height <= 0 {
+		return VideoStats{}, errors.New("y4m: missing frame size")
+	}
+	if opts.FrameRate > 0 {
+		fps = opts.FrameRate
+	}
+
+	// Chroma plane size and subsampling shifts
+	var cw, ch, sx, sy int
+	switch {
+	case strings.HasPrefix(chroma, "420") && !strings.Contains(chroma, "p1"):
+		cw, ch, sx, sy = (width+1)/2, (height+1)/2, 1, 1
+	case chroma == "422":
+		cw, ch, sx = (width+1)/2, height, 1
+	case chroma == "444":
+		cw, ch = width, height
+	case chroma == "mono":
+	default:
+		return VideoStats{}, fmt.Errorf("y4m: unsupported color space %q", chroma)
+	}
+
+	buf := make([]byte, width*height+2*cw*ch)
+	yp, cb, cr := buf[:width*height], buf[width*height:width*height+cw*ch], buf[width*height+cw*ch:]
+	next := func() error {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("y4m: reading frame header: %w", err)
+		}
+		if !strings.HasPrefix(line, "FRAME") {
+			return errors.New("y4m: missing FRAME marker")
+		}
+		if _, err := io.ReadFull(br, buf); err != nil {
+			// The FRAME marker promised a frame, so even a clean end is early
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("y4m: reading frame: %w", err)
+		}
+		return nil
+	}
+	convert := func(x, y, n int, dst []byte) {
+		for i := 0; i < n; i++ {
+			var u, v uint8 = 128, 128
+			if cw > 0 {
+				ci := (y>>sy)*cw + (x+i)>>sx
+				u, v = cb[ci], cr[ci]
+			}
+			var r, g, b uint8
+			if fullRange {
+				r, g, b = color.YCbCrToRGB(yp[y*width+x+i], u, v)
+			} else {
+				r, g, b = limitedYCbCrToRGB(yp[y*width+x+i], u, v)
+			}
+			put565(dst[i*2:], bgr565(r, g, b))
+		}
+	}
+	return d.playVideo(ctx, opts, fps, width, height, next, convert)
+}
+
+// limitedYCbCrToRGB converts BT.601 limited range (16-235) video levels
+func limitedYCbCrToRGB(y, cb, cr uint8) (uint8, uint8, uint8) {
+	c := 298 * (int32(y) - 16)
+	d := int32(cb) - 128
+	e := int32(cr) - 128
+	return clamp8((c + 409*e + 128) >> 8),
+		clamp8((c - 100*d - 208*e + 128) >> 8),
+		clamp8((c + 516*d + 128) >> 8)
+}
+
+func clamp8(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// playVideo shows the frames loaded by next at the given rate. convert
+// writes n pixels of the current frame from (x, y) as RGB565 into dst.
+// next returns io.EOF at the end of the stream.
+func (d *Device) playVideo(ctx context.Context, opts VideoOptions, fps float64, width, height int,
+	next func() error, convert func(x, y, n int, dst []byte)) (VideoStats, error) {
+	var stats VideoStats
+	if fps <= 0 {
+		return stats, errors.New("video: unknown frame rate")
+	}
+	interval := time.Duration(float64(time.Second) / fps)
+	x, y := int(opts.X), int(opts.Y)
+	vis := image.Rect(x, y, x+width, y+height).Intersect(d.bounds())
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	start := time.Now()
+	for k := 0; ; k++ {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if err := next(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return stats, err
+		}
+
+		due := start.Add(time.Duration(k) * interval)
+		if k > 0 && time.Since(due) > interval {
+			stats.Dropped++
+			continue
+		}
+		if wait := time.Until(due); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if opts.VSync {
+			d.Sync()
+		}
+
+		if !vis.Empty() {
+			err := d.blitRows(int16(vis.Min.X), int16(vis.Min.Y), int16(vis.Dx()), int16(vis.Dy()), func(row int, dst []byte) {
+				convert(vis.Min.X-x, vis.Min.Y-y+row, vis.Dx(), dst)
+			})
+			if err != nil {
+				return stats, err
+			}
+		}
+		stats.Frames++
+	}
+}
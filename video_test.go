@@ -0,0 +1,110 @@
+package gc9307
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// errReader returns data, then err.
+type errReader struct {
+	data []byte
+	err  error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// y4mFrame returns a FRAME of a 4x2 4:2:0 stream in one color.
+func y4mFrame(y, u, v byte) string {
+	return "FRAME\n" + string(bytes.Repeat([]byte{y}, 8)) + string([]byte{u, u, v, v})
+}
+
+func TestPlayY4M(t *testing.T) {
+	const header = "YUV4MPEG2 W4 H2 F100:1 Ip A1:1 C420jpeg\n"
+	white := y4mFrame(235, 128, 128)
+	for _, tc := range []struct {
+		name    string
+		stream  string
+		opts    VideoOptions
+		frames  int
+		wantErr string // Part of the error, empty for none
+	}{
+		{"TwoFrames", header + white + white, VideoOptions{}, 2, ""},
+		{"Empty", header, VideoOptions{}, 0, ""},
+		{"Mono", "YUV4MPEG2 W4 H2 F25:1 Cmono\nFRAME\n" + strings.Repeat("\xEB", 8), VideoOptions{}, 1, ""},
+		{"FrameRateOption", "YUV4MPEG2 W4 H2\n" + white, VideoOptions{FrameRate: 100}, 1, ""},
+		{"NoFrameRate", "YUV4MPEG2 W4 H2\n" + white, VideoOptions{}, 0, "unknown frame rate"},
+		{"NotY4M", "RIFF\n", VideoOptions{}, 0, "not a YUV4MPEG2 stream"},
+		{"NoHeader", "", VideoOptions{}, 0, "reading header"},
+		{"NoSize", "YUV4MPEG2 F25:1\n", VideoOptions{}, 0, "missing frame size"},
+		{"ColorSpace", "YUV4MPEG2 W4 H2 F25:1 C420p10\n", VideoOptions{}, 0, "unsupported color space"},
+		{"NoMarker", header + "FRAMX\n" + white[6:], VideoOptions{}, 0, "missing FRAME marker"},
+		{"TruncatedFrame", header + white + white[:10], VideoOptions{}, 1, "unexpected EOF"},
+		{"MarkerOnly", header + white + "FRAME\n", VideoOptions{}, 1, "unexpected EOF"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, r := recorderDevice(t)
+			stats, err := d.PlayY4M(context.Background(), strings.NewReader(tc.stream), tc.opts)
+			if tc.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("error %v, want %q", err, tc.wantErr)
+			}
+			if stats.Frames+stats.Dropped != tc.frames {
+				t.Errorf("%d frames, want %d", stats.Frames+stats.Dropped, tc.frames)
+			}
+			// Limited range white is white
+			if pix := sentPixels(r); tc.frames > 0 && !bytes.Equal(pix[:16], bytes.Repeat([]byte{0xFF}, 16)) {
+				t.Errorf("frame pixels % X", pix[:16])
+			}
+		})
+	}
+}
+
+// Read errors are returned as they are, not as a truncated stream.
+func TestPlayY4MReadError(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+	d, _ := recorderDevice(t)
+	stream := "YUV4MPEG2 W4 H2 F100:1\n" + y4mFrame(16, 128, 128)[:9]
+	_, err := d.PlayY4M(context.Background(), &errReader{[]byte(stream), errBroken}, VideoOptions{})
+	if !errors.Is(err, errBroken) {
+		t.Errorf("error %v, want %v", err, errBroken)
+	}
+}
+
+func TestPlayRGB565(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format RawFormat
+		frame  []byte // One 2x1 frame
+		want   []byte
+	}{
+		{"BigEndian", RawRGB565, []byte{0xF8, 0x00, 0x00, 0x1F}, []byte{0x00, 0x1F, 0xF8, 0x00}},
+		{"LittleEndian", RawRGB565LE, []byte{0x00, 0xF8, 0x1F, 0x00}, []byte{0x00, 0x1F, 0xF8, 0x00}},
+		{"Native", RawNative, []byte{0x12, 0x34, 0x56, 0x78}, []byte{0x12, 0x34, 0x56, 0x78}},
+	} {
+		d, r := recorderDevice(t)
+		stream := append(append([]byte(nil), tc.frame...), tc.frame[:3]...)
+		stats, err := d.PlayRGB565(context.Background(), bytes.NewReader(stream), 2, 1, tc.format, VideoOptions{FrameRate: 100})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: error %v, want a truncated last frame", tc.name, err)
+		}
+		if stats.Frames != 1 {
+			t.Errorf("%s: %d frames, want 1", tc.name, stats.Frames)
+		}
+		if pix := sentPixels(r); !bytes.Equal(pix, tc.want) {
+			t.Errorf("%s: pixels % X, want % X", tc.name, pix, tc.want)
+		}
+	}
+}
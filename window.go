@@ -0,0 +1,64 @@
+package gc9307
+
+// WindowStats counts the address window commands sent to the display and the
+// ones skipped because the display already had the same range programmed.
+type WindowStats struct {
+	ColumnSent    uint64 // CASET commands sent
+	ColumnSkipped uint64 // CASET commands skipped
+	RowSent       uint64 // RASET commands sent
+	RowSkipped    uint64 // RASET commands skipped
+	MemoryWrites  uint64 // RAMWR commands sent, one per window setup
+}
+
+// windowCache tracks the column and row ranges last sent with CASET and RASET
+type windowCache struct {
+	valid  bool
+	x0, x1 int16
+	y0, y1 int16
+	stats  WindowStats
+}
+
+// update records a new window and reports which ranges need to be sent
+func (c *windowCache) update(x0, x1, y0, y1 int16) (sendColumns, sendRows bool) {
+	sendColumns = !c.valid || c.x0 != x0 || c.x1 != x1
+	sendRows = !c.valid || c.y0 != y0 || c.y1 != y1
+
+	if sendColumns {
+		c.stats.ColumnSent++
+	} else {
+		c.stats.ColumnSkipped++
+	}
+	if sendRows {
+		c.stats.RowSent++
+	} else {
+		c.stats.RowSkipped++
+	}
+	c.stats.MemoryWrites++
+
+	c.valid = true
+	c.x0, c.x1 = x0, x1
+	c.y0, c.y1 = y0, y1
+	return sendColumns, sendRows
+}
+
+// invalidate forces the next window setup to send both ranges
+func (c *windowCache) invalidate() {
+	c.valid = false
+}
+
+// WindowStats returns the window cache counters
+func (d *Device) WindowStats() WindowStats {
+	return d.window.stats
+}
+
+// ResetWindowStats clears the window cache counters
+func (d *Device) ResetWindowStats() {
+	d.window.stats = WindowStats{}
+}
+
+// InvalidateWindow forgets the cached address window, so the next draw sends
+// CASET and RASET again. Use it after talking to the display behind the
+// driver's back, e.g. through the SPI connection directly.
+func (d *Device) InvalidateWindow() {
+	d.window.invalidate()
+}